@@ -0,0 +1,175 @@
+package aws
+
+/**
+ * @see https://github.com/aws/aws-sdk-go/blob/master/service/autoscaling/api.go
+ */
+import (
+	"errors"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+)
+
+var autoScalingCfg *awssdk.Config
+
+func init() {
+	autoScalingCfg = config()
+}
+
+func autoScalingClient() *autoscaling.AutoScaling {
+	return autoscaling.New(newSession(), autoScalingCfg)
+}
+
+// ErrNoSuchGroup means that the auto scaling group does not exist
+var ErrNoSuchGroup = errors.New("the auto scaling group does not exist")
+
+// ErrCapacityOutOfRange means that the desired capacity is not between
+// the minimum and the maximum size of the group
+var ErrCapacityOutOfRange = errors.New("the desired capacity should be between the min and max size of the group")
+
+// AutoScalingProcesses are the processes which can be suspended or resumed
+var AutoScalingProcesses = []string{
+	"Launch",
+	"Terminate",
+	"HealthCheck",
+	"ReplaceUnhealthy",
+	"AZRebalance",
+	"AlarmNotification",
+	"ScheduledActions",
+	"AddToLoadBalancer",
+	"InstanceRefresh",
+}
+
+// AutoScalingGroup represents an auto scaling group with its member instances
+type AutoScalingGroup struct {
+	*autoscaling.Group
+	Members []*ec2.Instance `json:"Members"`
+}
+
+// AutoScalingGroups returns auto scaling groups.
+// All the groups are returned when no name is specified.
+func AutoScalingGroups(names []string) (groups []*autoscaling.Group, e error) {
+	req := &autoscaling.DescribeAutoScalingGroupsInput{}
+	if len(names) > 0 {
+		req.AutoScalingGroupNames = awssdk.StringSlice(names)
+	}
+	groups = []*autoscaling.Group{}
+	err := autoScalingClient().DescribeAutoScalingGroupsPages(req, func(res *autoscaling.DescribeAutoScalingGroupsOutput, last bool) bool {
+		groups = append(groups, res.AutoScalingGroups...)
+		return true
+	})
+	if err != nil {
+		logs.Error.Print("Could not describe Auto Scaling groups.")
+		return nil, err
+	}
+	return groups, nil
+}
+
+// AutoScalingGroupByName returns a specified auto scaling group along with
+// the ec2 instances which belong to it, or nil when it does not exist
+func AutoScalingGroupByName(name string) (group *AutoScalingGroup, e error) {
+	groups, err := AutoScalingGroups([]string{name})
+	if err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	group = &AutoScalingGroup{Group: groups[0], Members: []*ec2.Instance{}}
+
+	ids := []*string{}
+	for _, instance := range group.Instances {
+		ids = append(ids, instance.InstanceId)
+	}
+	if len(ids) == 0 {
+		return group, nil
+	}
+	err = ec2Client().DescribeInstancesPages(&ec2.DescribeInstancesInput{InstanceIds: ids},
+		func(res *ec2.DescribeInstancesOutput, last bool) bool {
+			for _, reservation := range res.Reservations {
+				group.Members = append(group.Members, reservation.Instances...)
+			}
+			return true
+		})
+	if err != nil {
+		logs.Error.Printf("Could not describe EC2 Instances of %s.", name)
+		return nil, err
+	}
+	return group, nil
+}
+
+// AutoScalingSetCapacity changes the desired capacity of a group
+func AutoScalingSetCapacity(name string, desired int64, honorCooldown bool) error {
+	groups, err := AutoScalingGroups([]string{name})
+	if err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		return ErrNoSuchGroup
+	}
+	if desired < awssdk.Int64Value(groups[0].MinSize) || desired > awssdk.Int64Value(groups[0].MaxSize) {
+		return ErrCapacityOutOfRange
+	}
+	_, err = autoScalingClient().SetDesiredCapacity(&autoscaling.SetDesiredCapacityInput{
+		AutoScalingGroupName: awssdk.String(name),
+		DesiredCapacity:      awssdk.Int64(desired),
+		HonorCooldown:        awssdk.Bool(honorCooldown),
+	})
+	return err
+}
+
+// AutoScalingSuspend suspends processes of a group, or all of them with nothing
+func AutoScalingSuspend(name string, processes []string) error {
+	_, err := autoScalingClient().SuspendProcesses(&autoscaling.ScalingProcessQuery{
+		AutoScalingGroupName: awssdk.String(name),
+		ScalingProcesses:     autoScalingProcesses(processes),
+	})
+	return err
+}
+
+// AutoScalingResume resumes processes of a group, or all of them with nothing
+func AutoScalingResume(name string, processes []string) error {
+	_, err := autoScalingClient().ResumeProcesses(&autoscaling.ScalingProcessQuery{
+		AutoScalingGroupName: awssdk.String(name),
+		ScalingProcesses:     autoScalingProcesses(processes),
+	})
+	return err
+}
+
+// AutoScalingActivities returns recent scaling activities of a group, newest first
+func AutoScalingActivities(name string, limit int64) (activities []*autoscaling.Activity, e error) {
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+	res, err := autoScalingClient().DescribeScalingActivities(&autoscaling.DescribeScalingActivitiesInput{
+		AutoScalingGroupName: awssdk.String(name),
+		MaxRecords:           awssdk.Int64(limit),
+	})
+	if err != nil {
+		logs.Error.Printf("Could not describe scaling activities of %s.", name)
+		return nil, err
+	}
+	if res.Activities == nil {
+		return []*autoscaling.Activity{}, nil
+	}
+	return res.Activities, nil
+}
+
+// AutoScalingValidProcess checks if a process can be suspended or resumed
+func AutoScalingValidProcess(process string) bool {
+	for _, candidate := range AutoScalingProcesses {
+		if candidate == process {
+			return true
+		}
+	}
+	return false
+}
+
+func autoScalingProcesses(processes []string) []*string {
+	if len(processes) == 0 {
+		return nil
+	}
+	return awssdk.StringSlice(processes)
+}
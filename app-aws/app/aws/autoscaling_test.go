@@ -0,0 +1,92 @@
+package aws
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+const autoScalingGroupXML = `<DescribeAutoScalingGroupsResponse><DescribeAutoScalingGroupsResult><AutoScalingGroups><member>` +
+	`<AutoScalingGroupName>web</AutoScalingGroupName><MinSize>1</MinSize><MaxSize>4</MaxSize><DesiredCapacity>2</DesiredCapacity>` +
+	`<Instances><member><InstanceId>i-1</InstanceId></member><member><InstanceId>i-2</InstanceId></member></Instances>` +
+	`</member></AutoScalingGroups></DescribeAutoScalingGroupsResult></DescribeAutoScalingGroupsResponse>`
+
+func TestAutoScalingGroupByName(t *testing.T) {
+	defer fakeQueryEndpoint(&autoScalingCfg, func(action string, form url.Values) (int, string) {
+		if form.Get("AutoScalingGroupNames.member.1") != "web" {
+			return http.StatusOK, `<DescribeAutoScalingGroupsResponse><DescribeAutoScalingGroupsResult><AutoScalingGroups/>` +
+				`</DescribeAutoScalingGroupsResult></DescribeAutoScalingGroupsResponse>`
+		}
+		return http.StatusOK, autoScalingGroupXML
+	})()
+	var ids []string
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		ids = []string{form.Get("InstanceId.1"), form.Get("InstanceId.2")}
+		return http.StatusOK, `<DescribeInstancesResponse><reservationSet><item><instancesSet>` +
+			`<item><instanceId>i-1</instanceId></item><item><instanceId>i-2</instanceId></item>` +
+			`</instancesSet></item></reservationSet></DescribeInstancesResponse>`
+	})()
+
+	actual, err := AutoScalingGroupByName("web")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if *actual.DesiredCapacity != 2 || len(actual.Members) != 2 {
+		t.Errorf("Expected a group with 2 members, but got %v", actual)
+	}
+	if len(ids) != 2 || ids[0] != "i-1" || ids[1] != "i-2" {
+		t.Errorf("Expected %v, but got %v", []string{"i-1", "i-2"}, ids)
+	}
+	actual, err = AutoScalingGroupByName("nothing")
+	if err != nil || actual != nil {
+		t.Errorf("Expected nothing, but got %v %v", actual, err)
+	}
+}
+
+func TestAutoScalingSetCapacity(t *testing.T) {
+	desired := ""
+	defer fakeQueryEndpoint(&autoScalingCfg, func(action string, form url.Values) (int, string) {
+		if action == "SetDesiredCapacity" {
+			desired = form.Get("DesiredCapacity")
+			return http.StatusOK, `<SetDesiredCapacityResponse></SetDesiredCapacityResponse>`
+		}
+		return http.StatusOK, autoScalingGroupXML
+	})()
+
+	if err := AutoScalingSetCapacity("web", 5, false); err != ErrCapacityOutOfRange {
+		t.Errorf("Expected %v, but got %v", ErrCapacityOutOfRange, err)
+	}
+	if desired != "" {
+		t.Errorf("Expected no changes, but got %v", desired)
+	}
+	if err := AutoScalingSetCapacity("web", 3, true); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if desired != "3" {
+		t.Errorf("Expected %v, but got %v", "3", desired)
+	}
+}
+
+func TestAutoScalingSuspend(t *testing.T) {
+	var form url.Values
+	defer fakeQueryEndpoint(&autoScalingCfg, func(action string, f url.Values) (int, string) {
+		form = f
+		return http.StatusOK, `<` + action + `Response></` + action + `Response>`
+	})()
+
+	if err := AutoScalingSuspend("web", []string{"Launch", "AZRebalance"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if form.Get("Action") != "SuspendProcesses" || form.Get("ScalingProcesses.member.2") != "AZRebalance" {
+		t.Errorf("Expected to suspend Launch and AZRebalance, but got %v", form)
+	}
+	if err := AutoScalingResume("web", nil); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if form.Get("Action") != "ResumeProcesses" || form.Get("ScalingProcesses.member.1") != "" {
+		t.Errorf("Expected to resume all the processes, but got %v", form)
+	}
+}
@@ -0,0 +1,80 @@
+package aws
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pottava/golang-microservices/app-aws/app/cache"
+	appcfg "github.com/pottava/golang-microservices/app-aws/app/config"
+)
+
+// describeCache keeps results of describe calls which are made on every
+// page load, and is invalidated by the actions which change them
+var describeCache = cache.New(*appcfg.NewConfig().CacheTTL)
+
+var errPartial = errors.New("some of the targets failed")
+
+// CacheTTL tells how long results of describe calls are cached
+func CacheTTL() time.Duration {
+	return describeCache.TTL
+}
+
+// cached answers a describe call from the cache, keyed by the call and its parameters.
+// Concurrent identical calls share one request to AWS.
+func cached(call string, params interface{}, fn func() (interface{}, error)) (interface{}, time.Duration, error) {
+	return describeCache.Do(fmt.Sprintf("%s:%+v", call, params), fn)
+}
+
+// invalidateEc2 forgets cached ec2 instances after they are changed
+func invalidateEc2() {
+	describeCache.Invalidate("ec2:")
+}
+
+// Ec2InstancesCached is Ec2Instances answered from the cache with the age of the result
+func Ec2InstancesCached(query Ec2InstanceQuery) (*Ec2InstancePage, time.Duration, error) {
+	value, age, err := cached("ec2:DescribeInstances", query, func() (interface{}, error) {
+		return Ec2Instances(query)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return value.(*Ec2InstancePage), age, nil
+}
+
+// Ec2InstanceCached is Ec2Instance answered from the cache with the age of the result
+func Ec2InstanceCached(id string) (*ec2.Instance, time.Duration, error) {
+	value, age, err := cached("ec2:DescribeInstances", id, func() (interface{}, error) {
+		return Ec2Instance(id)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return value.(*ec2.Instance), age, nil
+}
+
+// Ec2InstancesInRegionsCached is Ec2InstancesInRegions answered from the cache
+// with the age of the result
+func Ec2InstancesInRegionsCached(query Ec2InstanceQuery, targets []Target) (*Ec2Inventory, time.Duration) {
+	value, age, _ := cached("ec2:DescribeInstancesInRegions", []interface{}{query, targets}, func() (interface{}, error) {
+		inventory := Ec2InstancesInRegions(query, targets)
+		if len(inventory.Errors) > 0 {
+			// not to keep failures of some regions
+			return inventory, errPartial
+		}
+		return inventory, nil
+	})
+	return value.(*Ec2Inventory), age
+}
+
+// Ec2StatusSummaryCached is Ec2StatusSummary answered from the cache with the age of the result
+func Ec2StatusSummaryCached() (*Ec2HealthSummary, time.Duration, error) {
+	value, age, err := cached("ec2:DescribeInstanceStatus", nil, func() (interface{}, error) {
+		return Ec2StatusSummary()
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return value.(*Ec2HealthSummary), age, nil
+}
@@ -0,0 +1,37 @@
+package aws
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestEc2InstancesCached(t *testing.T) {
+	calls := 0
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		if action == "DescribeInstances" {
+			calls++
+			return http.StatusOK, `<DescribeInstancesResponse><reservationSet><item><instancesSet>` +
+				`<item><instanceId>i-1</instanceId></item></instancesSet></item></reservationSet></DescribeInstancesResponse>`
+		}
+		return http.StatusOK, ec2StateChangeXML("StopInstances", "instancesSet", "i-1", "running", "stopping")
+	})()
+	describeCache.Invalidate()
+
+	query := Ec2InstanceQuery{States: []string{"running"}, AllPages: true}
+	Ec2InstancesCached(query)
+	page, _, err := Ec2InstancesCached(query)
+	if err != nil || page.Count != 1 || calls != 1 {
+		t.Errorf("Expected the second call to be cached, but got %v calls", calls)
+		return
+	}
+	Ec2InstancesCached(Ec2InstanceQuery{States: []string{"stopped"}, AllPages: true})
+	if calls != 2 {
+		t.Errorf("Expected another query not to share the cache, but got %v calls", calls)
+	}
+	Ec2StopInstance("i-1", false)
+	Ec2InstancesCached(query)
+	if calls != 3 {
+		t.Errorf("Expected stopping an instance to invalidate the cache, but got %v calls", calls)
+	}
+}
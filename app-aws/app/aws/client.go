@@ -0,0 +1,178 @@
+package aws
+
+import (
+	"context"
+	"expvar"
+	"math/rand"
+	"sync"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	appcfg "github.com/pottava/golang-microservices/app-aws/app/config"
+)
+
+// Counters of AWS API calls by "{service}.{operation}",
+// which are published at /debug/vars along with the other expvars
+var (
+	apiRequests  = expvar.NewMap("aws_requests")
+	apiRetries   = expvar.NewMap("aws_retries")
+	apiThrottles = expvar.NewMap("aws_throttles")
+)
+
+var sharedSession *session.Session
+var sharedSessionOnce sync.Once
+
+// newSession returns the session all the clients are made from,
+// so that every call goes through the same rate limits and counters
+func newSession() *session.Session {
+	sharedSessionOnce.Do(func() {
+		cfg := appcfg.NewConfig()
+		limits := &apiLimiters{rate: float64(cfg.AwsRateLimit), burst: float64(cfg.AwsRateBurst), buckets: map[string]*tokenBucket{}}
+
+		sharedSession = session.New()
+		sharedSession.Handlers.Send.PushFront(func(r *request.Request) {
+			// a canceled request stops waiting here, and fails to be sent
+			// right after because its http request has the same context
+			limits.wait(r.Context(), apiName(r)+"@"+awssdk.StringValue(r.Config.Region))
+		})
+		sharedSession.Handlers.AfterRetry.PushFront(func(r *request.Request) {
+			if r.Error != nil && request.IsErrorThrottle(r.Error) {
+				apiThrottles.Add(apiName(r), 1)
+			}
+		})
+		sharedSession.Handlers.Complete.PushBack(func(r *request.Request) {
+			apiRequests.Add(apiName(r), 1)
+			if r.RetryCount > 0 {
+				apiRetries.Add(apiName(r), int64(r.RetryCount))
+			}
+		})
+	})
+	return sharedSession
+}
+
+func apiName(r *request.Request) string {
+	if r.Operation == nil {
+		return r.ClientInfo.ServiceName
+	}
+	return r.ClientInfo.ServiceName + "." + r.Operation.Name
+}
+
+// retryer backs off exponentially with full jitter, which spreads retries
+// of fan-out calls instead of making them hit AWS at the same moment again.
+// Throttled calls back off from a longer base delay.
+type retryer struct {
+	client.DefaultRetryer
+	base time.Duration
+	max  time.Duration
+}
+
+func newRetryer() request.Retryer {
+	cfg := appcfg.NewConfig()
+	retries := *cfg.AwsMaxRetries
+	if retries < 0 {
+		retries = 0
+	}
+	return retryer{
+		DefaultRetryer: client.DefaultRetryer{NumMaxRetries: retries},
+		base:           cfg.AwsRetryBase,
+		max:            cfg.AwsRetryMax,
+	}
+}
+
+// RetryRules returns how long to wait before the next retry
+func (r retryer) RetryRules(req *request.Request) time.Duration {
+	base := r.base
+	if req.IsErrorThrottle() {
+		base *= 5
+	}
+	return backoff(base, r.max, req.RetryCount)
+}
+
+// backoff picks a random delay up to base * 2^attempt, capped by max
+func backoff(base, max time.Duration, attempt int) time.Duration {
+	ceiling := max
+	if attempt < 30 {
+		if d := base << uint(attempt); d > 0 && d < max {
+			ceiling = d
+		}
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+// apiLimiters holds a token bucket for each AWS API in each region,
+// as AWS throttles calls per account, operation and region
+type apiLimiters struct {
+	rate    float64
+	burst   float64
+	mutex   sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// wait blocks until the API can be called, or returns the error of
+// the context when it is done before that
+func (limits *apiLimiters) wait(ctx context.Context, key string) error {
+	if limits.rate <= 0 {
+		return nil
+	}
+	limits.mutex.Lock()
+	bucket, found := limits.buckets[key]
+	if !found {
+		bucket = newTokenBucket(limits.rate, limits.burst, time.Now())
+		limits.buckets[key] = bucket
+	}
+	limits.mutex.Unlock()
+
+	delay := bucket.take(time.Now())
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// tokenBucket allows calls at the rate per second with bursts up to its size
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	mutex  sync.Mutex
+}
+
+func newTokenBucket(rate, burst float64, now time.Time) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: rate, burst: burst, tokens: burst, last: now}
+}
+
+// take reserves a token and tells how long to wait until it is available
+func (bucket *tokenBucket) take(now time.Time) time.Duration {
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+
+	if elapsed := now.Sub(bucket.last).Seconds(); elapsed > 0 {
+		bucket.tokens += elapsed * bucket.rate
+		if bucket.tokens > bucket.burst {
+			bucket.tokens = bucket.burst
+		}
+		bucket.last = now
+	}
+	bucket.tokens--
+	if bucket.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-bucket.tokens / bucket.rate * float64(time.Second))
+}
@@ -0,0 +1,106 @@
+package aws
+
+import (
+	"context"
+	"expvar"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Date(2016, 1, 2, 0, 0, 0, 0, time.UTC)
+	bucket := newTokenBucket(10, 2, now)
+
+	for i := 0; i < 2; i++ {
+		if delay := bucket.take(now); delay != 0 {
+			t.Errorf("Expected a burst not to wait, but got %v", delay)
+		}
+	}
+	if delay := bucket.take(now); delay != 100*time.Millisecond {
+		t.Errorf("Expected %v, but got %v", 100*time.Millisecond, delay)
+	}
+	if delay := bucket.take(now); delay != 200*time.Millisecond {
+		t.Errorf("Expected %v, but got %v", 200*time.Millisecond, delay)
+	}
+	now = now.Add(time.Second)
+	if delay := bucket.take(now); delay != 0 {
+		t.Errorf("Expected tokens to be refilled, but got %v", delay)
+	}
+}
+
+func TestApiLimitersWait(t *testing.T) {
+	limits := &apiLimiters{rate: 0.1, burst: 1, buckets: map[string]*tokenBucket{}}
+	if err := limits.wait(context.Background(), "ec2.DescribeInstances@us-east-1"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	// another region has a bucket of its own
+	if err := limits.wait(context.Background(), "ec2.DescribeInstances@ap-northeast-1"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	if err := limits.wait(ctx, "ec2.DescribeInstances@us-east-1"); err != context.DeadlineExceeded {
+		t.Errorf("Expected %v, but got %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("Expected the wait to stop with the context, but it took %v", elapsed)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 40; attempt++ {
+		ceiling := 100 * time.Millisecond << uint(attempt)
+		if attempt >= 8 {
+			ceiling = 20 * time.Second
+		}
+		for i := 0; i < 20; i++ {
+			if delay := backoff(100*time.Millisecond, 20*time.Second, attempt); delay < 0 || delay >= ceiling {
+				t.Errorf("Expected a delay under %v, but got %v", ceiling, delay)
+			}
+		}
+	}
+}
+
+func TestThrottledRetry(t *testing.T) {
+	calls := 0
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		if calls++; calls == 1 {
+			return http.StatusServiceUnavailable, ec2Error("RequestLimitExceeded")
+		}
+		return http.StatusOK, `<DescribeInstancesResponse><reservationSet/></DescribeInstancesResponse>`
+	})()
+	ec2Cfg = request.WithRetryer(ec2Cfg.Copy(), retryer{
+		DefaultRetryer: client.DefaultRetryer{NumMaxRetries: 2},
+		base:           time.Millisecond,
+		max:            10 * time.Millisecond,
+	})
+	throttles := counter(apiThrottles, "ec2.DescribeInstances")
+	retries := counter(apiRetries, "ec2.DescribeInstances")
+
+	if _, err := Ec2Instance("i-1"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if calls != 2 {
+		t.Errorf("Expected %v calls, but got %v", 2, calls)
+	}
+	if actual := counter(apiThrottles, "ec2.DescribeInstances"); actual != throttles+1 {
+		t.Errorf("Expected %v throttles, but got %v", throttles+1, actual)
+	}
+	if actual := counter(apiRetries, "ec2.DescribeInstances"); actual != retries+1 {
+		t.Errorf("Expected %v retries, but got %v", retries+1, actual)
+	}
+}
+
+func counter(counters *expvar.Map, key string) int64 {
+	if v, ok := counters.Get(key).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
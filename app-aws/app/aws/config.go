@@ -2,13 +2,21 @@
 package aws
 
 import (
+	"errors"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sts"
 	app "github.com/pottava/golang-microservices/app-aws/app/config"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
 )
 
 func config() *aws.Config {
@@ -17,7 +25,7 @@ func config() *aws.Config {
 	if cfg.AwsLog {
 		log = aws.LogLevel(aws.LogDebug)
 	}
-	return &aws.Config{
+	return request.WithRetryer(&aws.Config{
 		Credentials: credentials.NewChainCredentials(
 			[]credentials.Provider{
 				&credentials.EnvProvider{},
@@ -25,5 +33,123 @@ func config() *aws.Config {
 			}),
 		Region:   aws.String(os.Getenv("AWS_REGION")),
 		LogLevel: log,
+	}, newRetryer())
+}
+
+// Target represents a pair of a region and an account to call AWS APIs against
+type Target struct {
+	Region  string `json:"region"`
+	Account string `json:"account,omitempty"`
+	role    string
+}
+
+// Targets returns pairs of the configured regions and accounts.
+// Regions can be narrowed down with the argument, or all the configured
+// regions are used for "all" or nothing. The account which the service runs
+// with comes first and then the ones of the configured assumed roles.
+// Unknown region names are rejected.
+func Targets(regions []string) ([]Target, error) {
+	cfg := app.NewConfig()
+	if len(regions) == 0 || (len(regions) == 1 && regions[0] == "all") {
+		regions = cfg.AwsRegions
+	}
+	if len(regions) == 0 {
+		regions = []string{os.Getenv("AWS_REGION")}
+	}
+	for _, region := range regions {
+		if region = strings.TrimSpace(region); region != "" && !knownRegion(region) {
+			return nil, errors.New("unknown region: " + region)
+		}
+	}
+	roles := append([]string{""}, cfg.AwsAssumeRoles...)
+
+	targets := []Target{}
+	for _, role := range roles {
+		role = strings.TrimSpace(role)
+		account := accountOf(role)
+		if role == "" {
+			account = selfAccount()
+		}
+		for _, region := range regions {
+			if region = strings.TrimSpace(region); region == "" {
+				continue
+			}
+			targets = append(targets, Target{Region: region, Account: account, role: role})
+		}
+	}
+	return targets, nil
+}
+
+// knownRegion tells if any of the partitions AWS SDK knows has the region
+func knownRegion(region string) bool {
+	for _, partition := range endpoints.DefaultPartitions() {
+		if _, found := partition.Regions()[region]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// accountOf extracts an account ID from an IAM role ARN
+// e.g. arn:aws:iam::123456789012:role/name
+func accountOf(role string) string {
+	if parts := strings.Split(role, ":"); len(parts) > 4 {
+		return parts[4]
+	}
+	return ""
+}
+
+var stsCfg *aws.Config
+
+var selfAccountID string
+var selfAccountMutex sync.Mutex
+
+func init() {
+	stsCfg = config()
+}
+
+// selfAccount returns the account which the service runs with. It is asked
+// STS for once, and asked again later when STS could not tell it.
+func selfAccount() string {
+	selfAccountMutex.Lock()
+	defer selfAccountMutex.Unlock()
+
+	if selfAccountID != "" {
+		return selfAccountID
+	}
+	res, err := sts.New(newSession(), stsCfg).GetCallerIdentity(&sts.GetCallerIdentityInput{})
+	if err != nil {
+		logs.Warn.Printf("Could not get the account of the service. Error: %v", err)
+		return ""
+	}
+	selfAccountID = aws.StringValue(res.Account)
+	return selfAccountID
+}
+
+var assumedRoles = map[string]*credentials.Credentials{}
+var assumedRolesMutex sync.Mutex
+
+// configFor copies the base config for the target region and account
+func configFor(base *aws.Config, target Target) *aws.Config {
+	cfg := base.Copy()
+	if target.Region != "" {
+		cfg.Region = aws.String(target.Region)
+	}
+	if target.role != "" {
+		cfg.Credentials = assumeRole(target.role)
+	}
+	return cfg
+}
+
+// assumeRole caches credentials per role so that they are refreshed only when expired
+func assumeRole(role string) *credentials.Credentials {
+	assumedRolesMutex.Lock()
+	defer assumedRolesMutex.Unlock()
+
+	if creds, found := assumedRoles[role]; found {
+		return creds
 	}
+	creds := stscreds.NewCredentials(newSession().Copy(config()), role)
+	assumedRoles[role] = creds
+	return creds
 }
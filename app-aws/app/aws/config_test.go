@@ -0,0 +1,62 @@
+package aws
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// fakeSts points the sts client to a local endpoint which tells the account,
+// and returns a function to restore the client
+func fakeSts(account string, calls *int) func() {
+	restore := fakeQueryEndpoint(&stsCfg, func(action string, form url.Values) (int, string) {
+		*calls++
+		return http.StatusOK, `<GetCallerIdentityResponse><GetCallerIdentityResult>` +
+			`<Account>` + account + `</Account></GetCallerIdentityResult></GetCallerIdentityResponse>`
+	})
+	selfAccountID = ""
+	return func() {
+		restore()
+		selfAccountID = ""
+	}
+}
+
+func TestAccountOf(t *testing.T) {
+	actual := accountOf("arn:aws:iam::123456789012:role/inventory")
+	expected := "123456789012"
+	if actual != expected {
+		t.Errorf("Expected %v, but got %v", expected, actual)
+	}
+	actual = accountOf("")
+	expected = ""
+	if actual != expected {
+		t.Errorf("Expected %v, but got %v", expected, actual)
+	}
+}
+
+func TestTargets(t *testing.T) {
+	calls := 0
+	defer fakeSts("111122223333", &calls)()
+
+	actual, err := Targets([]string{"us-east-1", "eu-west-1"})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if len(actual) != 2 {
+		t.Errorf("Expected %v targets, but got %v", 2, actual)
+		return
+	}
+	for _, target := range actual {
+		if target.Account != "111122223333" {
+			t.Errorf("Expected %v, but got %v", "111122223333", target.Account)
+		}
+	}
+	Targets([]string{"ap-northeast-1"})
+	if calls != 1 {
+		t.Errorf("Expected the account to be asked for %v time, but got %v", 1, calls)
+	}
+	if _, err = Targets([]string{"us-east-1", "moon-1"}); err == nil {
+		t.Errorf("Expected an unknown region to be rejected, but got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+package aws
+
+import (
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// Ec2Address represents an Elastic IP, flagged when it's not associated
+// with anything and costs money for nothing
+type Ec2Address struct {
+	*ec2.Address
+	Unassociated bool `json:"Unassociated"`
+}
+
+// Ec2Addresses returns Elastic IPs
+func Ec2Addresses(allocationIDs []string) (addresses []*Ec2Address, e error) {
+	req := &ec2.DescribeAddressesInput{}
+	if len(allocationIDs) > 0 {
+		req.AllocationIds = awssdk.StringSlice(allocationIDs)
+	}
+	res, err := ec2Client().DescribeAddresses(req)
+	if err != nil {
+		return nil, err
+	}
+	addresses = []*Ec2Address{}
+	for _, address := range res.Addresses {
+		addresses = append(addresses, &Ec2Address{
+			Address:      address,
+			Unassociated: address.AssociationId == nil && address.InstanceId == nil && address.NetworkInterfaceId == nil,
+		})
+	}
+	return addresses, nil
+}
+
+// Ec2AddressByID returns a specified Elastic IP
+func Ec2AddressByID(allocationID string) (address *Ec2Address, e error) {
+	addresses, err := Ec2Addresses([]string{allocationID})
+	if err != nil || len(addresses) == 0 {
+		return nil, err
+	}
+	return addresses[0], nil
+}
+
+// Ec2AssociateAddress associates an Elastic IP with an instance
+func Ec2AssociateAddress(allocationID, instanceID string) error {
+	defer invalidateEc2()
+
+	_, err := ec2Client().AssociateAddress(&ec2.AssociateAddressInput{
+		AllocationId: awssdk.String(allocationID),
+		InstanceId:   awssdk.String(instanceID),
+	})
+	return err
+}
+
+// Ec2DisassociateAddress disassociates an Elastic IP from whatever it's associated with
+func Ec2DisassociateAddress(allocationID string) error {
+	defer invalidateEc2()
+
+	address, err := Ec2AddressByID(allocationID)
+	if err != nil || address == nil || address.AssociationId == nil {
+		return err
+	}
+	_, err = ec2Client().DisassociateAddress(&ec2.DisassociateAddressInput{
+		AssociationId: address.AssociationId,
+	})
+	return err
+}
+
+// Ec2ReleaseAddress releases an Elastic IP
+func Ec2ReleaseAddress(allocationID string) error {
+	defer invalidateEc2()
+
+	_, err := ec2Client().ReleaseAddress(&ec2.ReleaseAddressInput{
+		AllocationId: awssdk.String(allocationID),
+	})
+	return err
+}
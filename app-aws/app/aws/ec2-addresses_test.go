@@ -0,0 +1,74 @@
+package aws
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// fakeEc2Addresses answers with an associated and an unassociated Elastic IP,
+// and tells associations which were disassociated
+func fakeEc2Addresses(disassociated *[]string) func() {
+	addresses := map[string]string{
+		"eipalloc-1": `<item><publicIp>203.0.113.1</publicIp><allocationId>eipalloc-1</allocationId>` +
+			`<domain>vpc</domain><associationId>eipassoc-1</associationId><instanceId>i-1</instanceId>` +
+			`<networkInterfaceId>eni-1</networkInterfaceId></item>`,
+		"eipalloc-2": `<item><publicIp>203.0.113.2</publicIp><allocationId>eipalloc-2</allocationId>` +
+			`<domain>vpc</domain></item>`,
+	}
+	return fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		switch action {
+		case "DescribeAddresses":
+			body := `<DescribeAddressesResponse><addressesSet>`
+			if id := form.Get("AllocationId.1"); id != "" {
+				if _, found := addresses[id]; !found {
+					return http.StatusBadRequest, ec2Error("InvalidAllocationID.NotFound")
+				}
+				body += addresses[id]
+			} else {
+				body += addresses["eipalloc-1"] + addresses["eipalloc-2"]
+			}
+			return http.StatusOK, body + `</addressesSet></DescribeAddressesResponse>`
+		case "DisassociateAddress":
+			*disassociated = append(*disassociated, form.Get("AssociationId"))
+			return http.StatusOK, `<DisassociateAddressResponse><return>true</return></DisassociateAddressResponse>`
+		}
+		return http.StatusBadRequest, ec2Error("InvalidAction")
+	})
+}
+
+func TestEc2Addresses(t *testing.T) {
+	defer fakeEc2Addresses(&[]string{})()
+
+	actual, err := Ec2Addresses(nil)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if len(actual) != 2 {
+		t.Errorf("Expected %v addresses, but got %v", 2, len(actual))
+		return
+	}
+	if *actual[0].AllocationId != "eipalloc-1" || actual[0].Unassociated || *actual[0].InstanceId != "i-1" {
+		t.Errorf("Expected eipalloc-1 to be associated with i-1, but got %v", actual[0])
+	}
+	if *actual[1].AllocationId != "eipalloc-2" || !actual[1].Unassociated {
+		t.Errorf("Expected eipalloc-2 to be unassociated, but got %v", actual[1])
+	}
+}
+
+func TestEc2DisassociateAddress(t *testing.T) {
+	disassociated := []string{}
+	defer fakeEc2Addresses(&disassociated)()
+
+	if err := Ec2DisassociateAddress("eipalloc-1"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	// an unassociated address has nothing to disassociate
+	if err := Ec2DisassociateAddress("eipalloc-2"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if len(disassociated) != 1 || disassociated[0] != "eipassoc-1" {
+		t.Errorf("Expected only %v to be disassociated, but got %v", "eipassoc-1", disassociated)
+	}
+}
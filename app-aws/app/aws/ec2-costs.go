@@ -0,0 +1,145 @@
+package aws
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+)
+
+// Pricing represents a locally loaded pricing table of ec2 instances
+// e.g. {"currency": "USD", "hoursPerMonth": 730, "prices": {"ap-northeast-1": {"t2.micro": 0.02}}}
+type Pricing struct {
+	Currency      string                        `json:"currency"`
+	HoursPerMonth float64                       `json:"hoursPerMonth"`
+	Prices        map[string]map[string]float64 `json:"prices"`
+}
+
+// Ec2Cost represents an estimated cost of an instance
+type Ec2Cost struct {
+	InstanceID   string  `json:"instanceId"`
+	InstanceType string  `json:"instanceType"`
+	Region       string  `json:"region"`
+	Account      string  `json:"account,omitempty"`
+	State        string  `json:"state"`
+	Group        string  `json:"group,omitempty"`
+	Priced       bool    `json:"priced"`
+	Hourly       float64 `json:"hourly"`
+	Monthly      float64 `json:"monthly"`
+}
+
+// Ec2CostGroup represents estimated costs summed up by a tag value
+type Ec2CostGroup struct {
+	Group   string  `json:"group"`
+	Count   int     `json:"count"`
+	Hourly  float64 `json:"hourly"`
+	Monthly float64 `json:"monthly"`
+}
+
+// Ec2CostReport represents estimated costs of instances
+type Ec2CostReport struct {
+	Currency  string              `json:"currency"`
+	Hourly    float64             `json:"hourly"`
+	Monthly   float64             `json:"monthly"`
+	Instances []*Ec2Cost          `json:"instances"`
+	Groups    []*Ec2CostGroup     `json:"groups,omitempty"`
+	Unpriced  []string            `json:"unpriced"`
+	Errors    []*Ec2RegionalError `json:"errors"`
+}
+
+// LoadPricing reads a pricing table from a json file
+func LoadPricing(path string) (pricing *Pricing, e error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pricing = &Pricing{}
+	if err = json.Unmarshal(data, pricing); err != nil {
+		return nil, err
+	}
+	if pricing.Currency == "" {
+		pricing.Currency = "USD"
+	}
+	if pricing.HoursPerMonth <= 0 {
+		pricing.HoursPerMonth = 730
+	}
+	return pricing, nil
+}
+
+// Ec2Costs estimates costs of instances in the targets,
+// summing them up by the value of the tag unless it's empty
+func Ec2Costs(query Ec2InstanceQuery, targets []Target, pricing *Pricing, tag string) *Ec2CostReport {
+	return ec2CostReport(Ec2InstancesInRegions(query, targets), pricing, tag)
+}
+
+// ec2CostReport prices running instances. Stopped ones cost nothing for
+// their compute capacity, and unknown instance types are reported as unpriced.
+func ec2CostReport(inventory *Ec2Inventory, pricing *Pricing, tag string) *Ec2CostReport {
+	report := &Ec2CostReport{
+		Currency:  pricing.Currency,
+		Instances: []*Ec2Cost{},
+		Unpriced:  []string{},
+		Errors:    inventory.Errors,
+	}
+	groups := map[string]*Ec2CostGroup{}
+	unpriced := map[string]bool{}
+
+	for _, instance := range inventory.Instances {
+		cost := &Ec2Cost{
+			InstanceID:   awssdk.StringValue(instance.InstanceId),
+			InstanceType: awssdk.StringValue(instance.InstanceType),
+			Region:       instance.Region,
+			Account:      instance.Account,
+		}
+		if instance.State != nil {
+			cost.State = awssdk.StringValue(instance.State.Name)
+		}
+		hourly, found := pricing.Prices[cost.Region][cost.InstanceType]
+		if found {
+			cost.Priced = true
+		} else {
+			unpriced[cost.Region+"/"+cost.InstanceType] = true
+		}
+		if found && cost.State == "running" {
+			cost.Hourly = hourly
+			cost.Monthly = hourly * pricing.HoursPerMonth
+		}
+		report.Hourly += cost.Hourly
+		report.Monthly += cost.Monthly
+
+		if tag != "" {
+			for _, t := range instance.Tags {
+				if awssdk.StringValue(t.Key) == tag {
+					cost.Group = awssdk.StringValue(t.Value)
+				}
+			}
+			group, found := groups[cost.Group]
+			if !found {
+				group = &Ec2CostGroup{Group: cost.Group}
+				groups[cost.Group] = group
+			}
+			group.Count++
+			group.Hourly += cost.Hourly
+			group.Monthly += cost.Monthly
+		}
+		report.Instances = append(report.Instances, cost)
+	}
+	if tag != "" {
+		report.Groups = []*Ec2CostGroup{}
+		for _, group := range groups {
+			report.Groups = append(report.Groups, group)
+		}
+		sort.Slice(report.Groups, func(i, j int) bool {
+			if report.Groups[i].Monthly != report.Groups[j].Monthly {
+				return report.Groups[i].Monthly > report.Groups[j].Monthly
+			}
+			return report.Groups[i].Group < report.Groups[j].Group
+		})
+	}
+	for key := range unpriced {
+		report.Unpriced = append(report.Unpriced, key)
+	}
+	sort.Strings(report.Unpriced)
+	return report
+}
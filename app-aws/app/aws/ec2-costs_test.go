@@ -0,0 +1,51 @@
+package aws
+
+import (
+	"math"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func costedInstance(id, instanceType, state, team string) *ec2.Instance {
+	instance := &ec2.Instance{
+		InstanceId:   awssdk.String(id),
+		InstanceType: awssdk.String(instanceType),
+		State:        &ec2.InstanceState{Name: awssdk.String(state)},
+	}
+	if team != "" {
+		instance.Tags = []*ec2.Tag{&ec2.Tag{Key: awssdk.String("Team"), Value: awssdk.String(team)}}
+	}
+	return instance
+}
+
+func TestEc2CostReport(t *testing.T) {
+	inventory := &Ec2Inventory{Instances: []*Ec2RegionalInstance{
+		&Ec2RegionalInstance{Region: "ap-northeast-1", Instance: costedInstance("i-1", "t2.micro", "running", "web")},
+		&Ec2RegionalInstance{Region: "ap-northeast-1", Instance: costedInstance("i-2", "m4.large", "running", "web")},
+		&Ec2RegionalInstance{Region: "ap-northeast-1", Instance: costedInstance("i-3", "m4.large", "stopped", "db")},
+		&Ec2RegionalInstance{Region: "us-east-1", Instance: costedInstance("i-4", "x1.32xlarge", "running", "")},
+	}}
+	pricing := &Pricing{Currency: "USD", HoursPerMonth: 730, Prices: map[string]map[string]float64{
+		"ap-northeast-1": map[string]float64{"t2.micro": 0.02, "m4.large": 0.18},
+	}}
+	actual := ec2CostReport(inventory, pricing, "Team")
+
+	if math.Abs(actual.Hourly-0.2) > 1e-9 {
+		t.Errorf("Expected %v, but got %v", 0.2, actual.Hourly)
+	}
+	if math.Abs(actual.Monthly-0.2*730) > 1e-6 {
+		t.Errorf("Expected %v, but got %v", 0.2*730, actual.Monthly)
+	}
+	if len(actual.Unpriced) != 1 || actual.Unpriced[0] != "us-east-1/x1.32xlarge" {
+		t.Errorf("Expected [us-east-1/x1.32xlarge], but got %v", actual.Unpriced)
+	}
+	if len(actual.Groups) != 3 || actual.Groups[0].Group != "web" || actual.Groups[0].Count != 2 {
+		t.Errorf("Expected web to cost the most, but got %v", actual.Groups)
+		return
+	}
+	if actual.Groups[1].Group != "" || actual.Groups[2].Group != "db" || actual.Groups[2].Monthly != 0 {
+		t.Errorf("Expected untagged and then db, but got %v %v", actual.Groups[1], actual.Groups[2])
+	}
+}
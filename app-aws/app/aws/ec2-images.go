@@ -0,0 +1,167 @@
+package aws
+
+import (
+	"errors"
+	"sort"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// Ec2Image represents an AMI with how many instances use it. Usage counts
+// instances which have not been terminated, including stopped ones, which
+// are counted separately too. Terminated ones do not use the AMI anymore.
+type Ec2Image struct {
+	*ec2.Image
+	Usage       int      `json:"Usage"`
+	Stopped     int      `json:"Stopped"`
+	Terminated  int      `json:"Terminated"`
+	SnapshotIDs []string `json:"SnapshotIds"`
+}
+
+// Ec2DeregisterResult represents a deregistered AMI and its deleted snapshots
+type Ec2DeregisterResult struct {
+	ImageID   string               `json:"id"`
+	DryRun    bool                 `json:"dryRun"`
+	Snapshots []*Ec2SnapshotResult `json:"snapshots"`
+}
+
+// ErrImageInUse means that instances are still running from an AMI
+var ErrImageInUse = errors.New("the image is still used by instances")
+
+// Ec2Images returns AMIs owned by the account, the oldest first
+func Ec2Images() (images []*Ec2Image, e error) {
+	res, err := ec2Client().DescribeImages(&ec2.DescribeImagesInput{
+		Owners: []*string{awssdk.String("self")},
+	})
+	if err != nil {
+		return nil, err
+	}
+	instances, err := Ec2Instances(Ec2InstanceQuery{AllPages: true})
+	if err != nil {
+		return nil, err
+	}
+	return ec2ImageCatalog(res.Images, instances.Instances), nil
+}
+
+// Ec2ImageByID returns a specified AMI owned by the account
+func Ec2ImageByID(id string) (image *Ec2Image, e error) {
+	images, err := Ec2Images()
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range images {
+		if awssdk.StringValue(candidate.ImageId) == id {
+			return candidate, nil
+		}
+	}
+	return nil, nil
+}
+
+// Ec2DeregisterImage deregisters an AMI and deletes its snapshots which no other
+// AMI uses. AMIs which instances are running from are kept unless forced.
+// It returns nil when the AMI is not found.
+func Ec2DeregisterImage(id string, force, dryRun bool) (result *Ec2DeregisterResult, e error) {
+	images, err := Ec2Images()
+	if err != nil {
+		return nil, err
+	}
+	var image *Ec2Image
+	for _, candidate := range images {
+		if awssdk.StringValue(candidate.ImageId) == id {
+			image = candidate
+		}
+	}
+	if image == nil {
+		return nil, nil
+	}
+	if image.Usage > 0 && !force {
+		return nil, ErrImageInUse
+	}
+	_, err = ec2Client().DeregisterImage(&ec2.DeregisterImageInput{
+		ImageId: awssdk.String(id),
+		DryRun:  awssdk.Bool(dryRun),
+	})
+	if err != nil && !(dryRun && Ec2DryRunSucceeded(err)) {
+		return nil, err
+	}
+	result = &Ec2DeregisterResult{ImageID: id, DryRun: dryRun, Snapshots: []*Ec2SnapshotResult{}}
+	for _, snapshot := range ec2OrphanedSnapshots(image, images) {
+		if dryRun {
+			result.Snapshots = append(result.Snapshots, &Ec2SnapshotResult{SnapshotID: snapshot})
+			continue
+		}
+		deleted := &Ec2SnapshotResult{SnapshotID: snapshot, Success: true}
+		if err := Ec2DeleteSnapshot(snapshot); err != nil {
+			deleted.Success = false
+			deleted.Message = err.Error()
+		}
+		result.Snapshots = append(result.Snapshots, deleted)
+	}
+	return result, nil
+}
+
+func ec2ImageCatalog(images []*ec2.Image, instances []*ec2.Instance) []*Ec2Image {
+	usage, stopped, terminated := map[string]int{}, map[string]int{}, map[string]int{}
+	for _, instance := range instances {
+		id := awssdk.StringValue(instance.ImageId)
+		state := ""
+		if instance.State != nil {
+			state = awssdk.StringValue(instance.State.Name)
+		}
+		switch state {
+		case ec2.InstanceStateNameTerminated, ec2.InstanceStateNameShuttingDown:
+			terminated[id]++
+			continue
+		case ec2.InstanceStateNameStopped, ec2.InstanceStateNameStopping:
+			stopped[id]++
+		}
+		usage[id]++
+	}
+	catalog := []*Ec2Image{}
+	for _, image := range images {
+		id := awssdk.StringValue(image.ImageId)
+		catalog = append(catalog, &Ec2Image{
+			Image:       image,
+			Usage:       usage[id],
+			Stopped:     stopped[id],
+			Terminated:  terminated[id],
+			SnapshotIDs: ec2ImageSnapshots(image),
+		})
+	}
+	// creation dates are in ISO 8601, so they can be sorted as strings
+	sort.SliceStable(catalog, func(i, j int) bool {
+		return awssdk.StringValue(catalog[i].CreationDate) < awssdk.StringValue(catalog[j].CreationDate)
+	})
+	return catalog
+}
+
+func ec2ImageSnapshots(image *ec2.Image) []string {
+	snapshots := []string{}
+	for _, mapping := range image.BlockDeviceMappings {
+		if mapping.Ebs != nil && mapping.Ebs.SnapshotId != nil {
+			snapshots = append(snapshots, awssdk.StringValue(mapping.Ebs.SnapshotId))
+		}
+	}
+	return snapshots
+}
+
+// ec2OrphanedSnapshots returns snapshots of the image which no other images use
+func ec2OrphanedSnapshots(image *Ec2Image, images []*Ec2Image) []string {
+	used := map[string]bool{}
+	for _, other := range images {
+		if awssdk.StringValue(other.ImageId) == awssdk.StringValue(image.ImageId) {
+			continue
+		}
+		for _, snapshot := range other.SnapshotIDs {
+			used[snapshot] = true
+		}
+	}
+	orphaned := []string{}
+	for _, snapshot := range image.SnapshotIDs {
+		if !used[snapshot] {
+			orphaned = append(orphaned, snapshot)
+		}
+	}
+	return orphaned
+}
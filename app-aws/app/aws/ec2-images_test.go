@@ -0,0 +1,50 @@
+package aws
+
+import (
+	"reflect"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func image(id, created string, snapshots ...string) *ec2.Image {
+	image := &ec2.Image{ImageId: awssdk.String(id), CreationDate: awssdk.String(created)}
+	for _, snapshot := range snapshots {
+		image.BlockDeviceMappings = append(image.BlockDeviceMappings, &ec2.BlockDeviceMapping{
+			Ebs: &ec2.EbsBlockDevice{SnapshotId: awssdk.String(snapshot)},
+		})
+	}
+	return image
+}
+
+func TestEc2ImageCatalog(t *testing.T) {
+	images := []*ec2.Image{
+		image("ami-2", "2016-02-01T00:00:00.000Z", "snap-2", "snap-shared"),
+		image("ami-1", "2016-01-01T00:00:00.000Z", "snap-1", "snap-shared"),
+	}
+	instance := func(image, state string) *ec2.Instance {
+		return &ec2.Instance{ImageId: awssdk.String(image), State: &ec2.InstanceState{Name: awssdk.String(state)}}
+	}
+	instances := []*ec2.Instance{
+		instance("ami-1", "running"),
+		instance("ami-1", "stopped"),
+		instance("ami-1", "terminated"),
+		instance("ami-2", "terminated"),
+		instance("ami-public", "running"),
+	}
+	actual := ec2ImageCatalog(images, instances)
+
+	if *actual[0].ImageId != "ami-1" || actual[0].Usage != 2 || actual[0].Stopped != 1 || actual[0].Terminated != 1 {
+		t.Errorf("Expected ami-1 to be the oldest and used by 2 instances, but got %v", actual[0])
+		return
+	}
+	if actual[1].Usage != 0 || actual[1].Terminated != 1 {
+		t.Errorf("Expected ami-2 not to be used by terminated instances, but got %v", actual[1])
+	}
+	orphaned := ec2OrphanedSnapshots(actual[1], actual)
+	expected := []string{"snap-2"}
+	if !reflect.DeepEqual(orphaned, expected) {
+		t.Errorf("Expected %v, but got %v", expected, orphaned)
+	}
+}
@@ -0,0 +1,47 @@
+package aws
+
+import (
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// Ec2KeyPair represents a key pair with instances which were launched with it
+type Ec2KeyPair struct {
+	*ec2.KeyPairInfo
+	InstanceIDs []string `json:"InstanceIds"`
+}
+
+// Ec2KeyPairs returns key pairs with instances which reference them
+func Ec2KeyPairs(names []string) (keys []*Ec2KeyPair, e error) {
+	req := &ec2.DescribeKeyPairsInput{}
+	if len(names) > 0 {
+		req.KeyNames = awssdk.StringSlice(names)
+	}
+	res, err := ec2Client().DescribeKeyPairs(req)
+	if err != nil {
+		return nil, err
+	}
+	instances, err := Ec2Instances(Ec2InstanceQuery{AllPages: true})
+	if err != nil {
+		return nil, err
+	}
+	return ec2KeyPairUsage(res.KeyPairs, instances.Instances), nil
+}
+
+func ec2KeyPairUsage(pairs []*ec2.KeyPairInfo, instances []*ec2.Instance) []*Ec2KeyPair {
+	users := map[string][]string{}
+	for _, instance := range instances {
+		if name := awssdk.StringValue(instance.KeyName); name != "" {
+			users[name] = append(users[name], awssdk.StringValue(instance.InstanceId))
+		}
+	}
+	keys := []*Ec2KeyPair{}
+	for _, pair := range pairs {
+		instanceIDs := users[awssdk.StringValue(pair.KeyName)]
+		if instanceIDs == nil {
+			instanceIDs = []string{}
+		}
+		keys = append(keys, &Ec2KeyPair{KeyPairInfo: pair, InstanceIDs: instanceIDs})
+	}
+	return keys
+}
@@ -0,0 +1,71 @@
+package aws
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestEc2KeyPairUsage(t *testing.T) {
+	pairs := []*ec2.KeyPairInfo{
+		&ec2.KeyPairInfo{KeyName: awssdk.String("old")},
+		&ec2.KeyPairInfo{KeyName: awssdk.String("new")},
+	}
+	instances := []*ec2.Instance{
+		&ec2.Instance{InstanceId: awssdk.String("i-1"), KeyName: awssdk.String("old")},
+		&ec2.Instance{InstanceId: awssdk.String("i-2"), KeyName: awssdk.String("old")},
+		&ec2.Instance{InstanceId: awssdk.String("i-3")},
+	}
+	actual := ec2KeyPairUsage(pairs, instances)
+
+	if !reflect.DeepEqual(actual[0].InstanceIDs, []string{"i-1", "i-2"}) {
+		t.Errorf("Expected %v, but got %v", []string{"i-1", "i-2"}, actual[0].InstanceIDs)
+	}
+	if len(actual[1].InstanceIDs) != 0 {
+		t.Errorf("Expected no instance, but got %v", actual[1].InstanceIDs)
+	}
+}
+
+func TestEc2KeyPairs(t *testing.T) {
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		switch action {
+		case "DescribeKeyPairs":
+			if form.Get("KeyName.1") == "unknown" {
+				return http.StatusBadRequest, ec2Error("InvalidKeyPair.NotFound")
+			}
+			return http.StatusOK, `<DescribeKeyPairsResponse><keySet>` +
+				`<item><keyName>ops</keyName><keyFingerprint>1f:51:ae</keyFingerprint></item>` +
+				`<item><keyName>unused</keyName><keyFingerprint>2a:04:9c</keyFingerprint></item>` +
+				`</keySet></DescribeKeyPairsResponse>`
+		case "DescribeInstances":
+			return http.StatusOK, `<DescribeInstancesResponse><reservationSet><item><instancesSet>` +
+				`<item><instanceId>i-1</instanceId><keyName>ops</keyName></item>` +
+				`<item><instanceId>i-2</instanceId></item>` +
+				`</instancesSet></item></reservationSet></DescribeInstancesResponse>`
+		}
+		return http.StatusBadRequest, ec2Error("InvalidAction")
+	})()
+
+	actual, err := Ec2KeyPairs(nil)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if len(actual) != 2 {
+		t.Errorf("Expected %v key pairs, but got %v", 2, len(actual))
+		return
+	}
+	if *actual[0].KeyName != "ops" || *actual[0].KeyFingerprint != "1f:51:ae" || !reflect.DeepEqual(actual[0].InstanceIDs, []string{"i-1"}) {
+		t.Errorf("Expected ops to be used by i-1, but got %v", actual[0])
+	}
+	if *actual[1].KeyName != "unused" || len(actual[1].InstanceIDs) != 0 {
+		t.Errorf("Expected unused to be used by nothing, but got %v", actual[1])
+	}
+	if _, err = Ec2KeyPairs([]string{"unknown"}); err == nil {
+		t.Errorf("Expected an unknown key pair to fail, but got %v", err)
+	}
+}
@@ -0,0 +1,162 @@
+package aws
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	app "github.com/pottava/golang-microservices/app-aws/app/config"
+)
+
+// Ec2MaxLaunchCount is the number of instances which can be launched at once
+const Ec2MaxLaunchCount = 20
+
+// Ec2LaunchRequest represents a launch profile name with overrides of its fields
+type Ec2LaunchRequest struct {
+	Profile          string            `json:"profile"`
+	Count            int64             `json:"count"`
+	ImageID          string            `json:"imageId"`
+	InstanceType     string            `json:"instanceType"`
+	SubnetID         string            `json:"subnetId"`
+	SecurityGroupIDs []string          `json:"securityGroupIds"`
+	KeyName          string            `json:"keyName"`
+	Tags             map[string]string `json:"tags"`
+	UserData         string            `json:"userData"`
+}
+
+// Ec2LaunchResult represents instances which have been launched
+type Ec2LaunchResult struct {
+	ReservationID string          `json:"reservationId"`
+	InstanceIDs   []string        `json:"instanceIds"`
+	Instances     []*ec2.Instance `json:"instances"`
+}
+
+// Ec2LaunchProfile finds a launch profile in the configurations
+func Ec2LaunchProfile(name string) (profile app.LaunchProfile, found bool) {
+	for _, candidate := range app.NewConfig().LaunchProfiles {
+		if candidate.Name == name {
+			return candidate, true
+		}
+	}
+	return app.LaunchProfile{}, false
+}
+
+// Merge fills fields which are not overridden with the ones of the profile.
+// Tags are merged, and the overrides win.
+func (req *Ec2LaunchRequest) Merge(profile app.LaunchProfile) {
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	if req.ImageID == "" {
+		req.ImageID = profile.ImageID
+	}
+	if req.InstanceType == "" {
+		req.InstanceType = profile.InstanceType
+	}
+	if req.SubnetID == "" {
+		req.SubnetID = profile.SubnetID
+	}
+	if len(req.SecurityGroupIDs) == 0 {
+		req.SecurityGroupIDs = profile.SecurityGroupIDs
+	}
+	if req.KeyName == "" {
+		req.KeyName = profile.KeyName
+	}
+	if req.UserData == "" {
+		req.UserData = profile.UserData
+	}
+	tags := map[string]string{}
+	for key, value := range profile.Tags {
+		tags[key] = value
+	}
+	for key, value := range req.Tags {
+		tags[key] = value
+	}
+	req.Tags = tags
+}
+
+// Ec2LaunchProblem represents a problem of a field in a launch request
+type Ec2LaunchProblem struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validate returns problems of the request field by field
+func (req *Ec2LaunchRequest) Validate() []*Ec2LaunchProblem {
+	problems := []*Ec2LaunchProblem{}
+	add := func(field, message string) {
+		problems = append(problems, &Ec2LaunchProblem{Field: field, Message: message})
+	}
+	if req.Count < 1 || req.Count > Ec2MaxLaunchCount {
+		add("count", fmt.Sprintf("must be between 1 and %d", Ec2MaxLaunchCount))
+	}
+	if !strings.HasPrefix(req.ImageID, "ami-") {
+		add("imageId", "must be an AMI ID like ami-xxxxxxxx")
+	}
+	if req.InstanceType == "" {
+		add("instanceType", "is required")
+	}
+	if req.SubnetID != "" && !strings.HasPrefix(req.SubnetID, "subnet-") {
+		add("subnetId", "must be a subnet ID like subnet-xxxxxxxx")
+	}
+	for _, id := range req.SecurityGroupIDs {
+		if !strings.HasPrefix(id, "sg-") {
+			add("securityGroupIds", id+" is not a security group ID")
+		}
+	}
+	if len(req.UserData) > 16*1024 {
+		add("userData", "must be 16KB or less")
+	}
+	for key, value := range req.Tags {
+		if key == "" || len(key) > 127 || len(value) > 255 || strings.HasPrefix(key, "aws:") {
+			add("tags", key+" is not a valid tag")
+		}
+	}
+	return problems
+}
+
+// Ec2Launch launches instances as requested
+func Ec2Launch(req Ec2LaunchRequest, dryRun bool) (result *Ec2LaunchResult, e error) {
+	defer invalidateEc2()
+
+	input := &ec2.RunInstancesInput{
+		ImageId:      awssdk.String(req.ImageID),
+		InstanceType: awssdk.String(req.InstanceType),
+		MinCount:     awssdk.Int64(req.Count),
+		MaxCount:     awssdk.Int64(req.Count),
+		DryRun:       awssdk.Bool(dryRun),
+	}
+	if req.SubnetID != "" {
+		input.SubnetId = awssdk.String(req.SubnetID)
+	}
+	if len(req.SecurityGroupIDs) > 0 {
+		input.SecurityGroupIds = awssdk.StringSlice(req.SecurityGroupIDs)
+	}
+	if req.KeyName != "" {
+		input.KeyName = awssdk.String(req.KeyName)
+	}
+	if req.UserData != "" {
+		input.UserData = awssdk.String(base64.StdEncoding.EncodeToString([]byte(req.UserData)))
+	}
+	if len(req.Tags) > 0 {
+		input.TagSpecifications = []*ec2.TagSpecification{&ec2.TagSpecification{
+			ResourceType: awssdk.String(ec2.ResourceTypeInstance),
+			Tags:         ec2TagList(req.Tags),
+		}}
+	}
+	res, err := ec2Client().RunInstances(input)
+	if err != nil {
+		return nil, err
+	}
+	result = &Ec2LaunchResult{
+		ReservationID: awssdk.StringValue(res.ReservationId),
+		InstanceIDs:   []string{},
+		Instances:     res.Instances,
+	}
+	for _, instance := range res.Instances {
+		result.InstanceIDs = append(result.InstanceIDs, awssdk.StringValue(instance.InstanceId))
+	}
+	return result, nil
+}
@@ -0,0 +1,59 @@
+package aws
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	app "github.com/pottava/golang-microservices/app-aws/app/config"
+)
+
+func TestEc2LaunchRequest(t *testing.T) {
+	req := &Ec2LaunchRequest{InstanceType: "t2.small", Tags: map[string]string{"Owner": "me"}}
+	req.Merge(app.LaunchProfile{
+		Name:             "web",
+		ImageID:          "ami-12345678",
+		InstanceType:     "t2.micro",
+		SecurityGroupIDs: []string{"sg-1"},
+		Tags:             map[string]string{"Role": "web", "Owner": "ops"},
+	})
+	if req.Count != 1 || req.ImageID != "ami-12345678" || req.InstanceType != "t2.small" {
+		t.Errorf("Expected the profile to be overridden, but got %+v", req)
+	}
+	if req.Tags["Role"] != "web" || req.Tags["Owner"] != "me" {
+		t.Errorf("Expected tags to be merged, but got %v", req.Tags)
+	}
+	if problems := req.Validate(); len(problems) != 0 {
+		t.Errorf("Expected no problem, but got %v", problems)
+	}
+
+	req = &Ec2LaunchRequest{Count: 100, ImageID: "foo", SecurityGroupIDs: []string{"default"}}
+	if problems := req.Validate(); len(problems) != 4 {
+		t.Errorf("Expected 4 problems, but got %v", problems)
+	}
+}
+
+func TestEc2Launch(t *testing.T) {
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		if action != "RunInstances" || form.Get("MinCount") != "2" || form.Get("TagSpecification.1.Tag.1.Key") != "Role" {
+			return http.StatusBadRequest, ec2Error("InvalidParameterValue")
+		}
+		return http.StatusOK, `<RunInstancesResponse><reservationId>r-1</reservationId><instancesSet>` +
+			`<item><instanceId>i-1</instanceId></item><item><instanceId>i-2</instanceId></item>` +
+			`</instancesSet></RunInstancesResponse>`
+	})()
+
+	actual, err := Ec2Launch(Ec2LaunchRequest{
+		Count:        2,
+		ImageID:      "ami-12345678",
+		InstanceType: "t2.micro",
+		Tags:         map[string]string{"Role": "web"},
+	}, false)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if actual.ReservationID != "r-1" || len(actual.InstanceIDs) != 2 || actual.InstanceIDs[1] != "i-2" {
+		t.Errorf("Expected r-1 with [i-1 i-2], but got %v %v", actual.ReservationID, actual.InstanceIDs)
+	}
+}
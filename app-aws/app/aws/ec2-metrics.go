@@ -0,0 +1,222 @@
+package aws
+
+/**
+ * @see https://github.com/aws/aws-sdk-go/blob/master/service/cloudwatch/api.go
+ */
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/pottava/golang-microservices/app-aws/app/cache"
+	appcfg "github.com/pottava/golang-microservices/app-aws/app/config"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+)
+
+var cloudWatchCfg *awssdk.Config
+
+var metricCache *cache.Cache
+
+func init() {
+	cloudWatchCfg = config()
+
+	metricCache = cache.New(metricCacheTTL)
+	if *appcfg.NewConfig().CacheTTL <= 0 {
+		metricCache.TTL = 0
+	}
+}
+
+func cloudWatchClient() *cloudwatch.CloudWatch {
+	return cloudwatch.New(newSession(), cloudWatchCfg)
+}
+
+// GetMetricStatistics returns at most 1440 datapoints at once
+const metricMaxDatapoints = 1440
+
+// metricCacheTTL is how long datapoints are served from the cache.
+// CloudWatch publishes basic monitoring metrics every 5 minutes,
+// so asking it again within a minute rarely shows anything new.
+// Nothing is cached when the describe cache is disabled either.
+const metricCacheTTL = time.Minute
+
+// Ec2MetricQuery represents conditions to retrieve a metric of an instance
+type Ec2MetricQuery struct {
+	Name      string
+	Statistic string
+	Period    time.Duration
+	From      time.Time
+	To        time.Time
+	MaxPoints int
+}
+
+// Datapoint represents a value of a metric at a time.
+// Its fields are named as nv.d3 charts read them by default.
+type Datapoint struct {
+	Timestamp time.Time `json:"x"`
+	Value     float64   `json:"y"`
+}
+
+// Ec2Metric represents time-series datapoints of a metric of an instance
+type Ec2Metric struct {
+	InstanceID string       `json:"instanceId"`
+	Name       string       `json:"name"`
+	Statistic  string       `json:"statistic"`
+	Unit       string       `json:"unit"`
+	Period     int64        `json:"period"`
+	From       time.Time    `json:"from"`
+	To         time.Time    `json:"to"`
+	Datapoints []*Datapoint `json:"datapoints"`
+}
+
+// Ec2Metrics returns datapoints of a metric of a specified instance.
+// The period is widened to keep the number of datapoints within what
+// CloudWatch returns at once, and then the datapoints are downsampled
+// to MaxPoints. The same query is answered from a cache for a minute.
+func Ec2Metrics(id string, query Ec2MetricQuery) (*Ec2Metric, error) {
+	query = query.normalize()
+
+	key := fmt.Sprintf("%s/%s/%s/%d/%d/%d/%d", id, query.Name, query.Statistic,
+		int64(query.Period.Seconds()), query.From.Unix(), query.To.Unix(), query.MaxPoints)
+	value, _, err := metricCache.Do(key, func() (interface{}, error) {
+		return ec2Metrics(id, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return value.(*Ec2Metric), nil
+}
+
+func ec2Metrics(id string, query Ec2MetricQuery) (*Ec2Metric, error) {
+	res, err := cloudWatchClient().GetMetricStatistics(&cloudwatch.GetMetricStatisticsInput{
+		Namespace:  awssdk.String("AWS/EC2"),
+		MetricName: awssdk.String(query.Name),
+		Dimensions: []*cloudwatch.Dimension{&cloudwatch.Dimension{
+			Name:  awssdk.String("InstanceId"),
+			Value: awssdk.String(id),
+		}},
+		Statistics: []*string{awssdk.String(query.Statistic)},
+		Period:     awssdk.Int64(int64(query.Period.Seconds())),
+		StartTime:  awssdk.Time(query.From),
+		EndTime:    awssdk.Time(query.To),
+	})
+	if err != nil {
+		logs.Error.Printf("Could not get %s of %s.", query.Name, id)
+		return nil, err
+	}
+	metric := &Ec2Metric{
+		InstanceID: id,
+		Name:       query.Name,
+		Statistic:  query.Statistic,
+		Period:     int64(query.Period.Seconds()),
+		From:       query.From,
+		To:         query.To,
+		Datapoints: []*Datapoint{},
+	}
+	for _, datapoint := range res.Datapoints {
+		if datapoint.Timestamp == nil {
+			continue
+		}
+		if metric.Unit == "" {
+			metric.Unit = awssdk.StringValue(datapoint.Unit)
+		}
+		metric.Datapoints = append(metric.Datapoints, &Datapoint{
+			Timestamp: *datapoint.Timestamp,
+			Value:     statisticOf(datapoint, query.Statistic),
+		})
+	}
+	sort.Sort(datapointsByTime(metric.Datapoints))
+	metric.Datapoints = downsample(metric.Datapoints, query.Statistic, query.MaxPoints)
+	return metric, nil
+}
+
+// normalize fills the query with defaults and aligns its range to the period
+// so that requests made within the same period share the cache
+func (query Ec2MetricQuery) normalize() Ec2MetricQuery {
+	if query.Name == "" {
+		query.Name = "CPUUtilization"
+	}
+	if query.Statistic == "" {
+		query.Statistic = cloudwatch.StatisticAverage
+	}
+	if query.To.IsZero() {
+		query.To = time.Now()
+	}
+	if query.From.IsZero() || !query.From.Before(query.To) {
+		query.From = query.To.Add(-3 * time.Hour)
+	}
+	if query.MaxPoints <= 0 {
+		query.MaxPoints = 300
+	}
+	// periods are multiples of 60 seconds
+	period := int64(query.Period.Seconds())
+	if period < 60 {
+		period = 300
+	}
+	period = (period + 59) / 60 * 60
+	span := int64(query.To.Sub(query.From).Seconds())
+	for span/period > metricMaxDatapoints {
+		period += 60
+	}
+	query.Period = time.Duration(period) * time.Second
+
+	query.From = query.From.Truncate(query.Period)
+	query.To = query.To.Truncate(query.Period).Add(query.Period)
+	return query
+}
+
+func statisticOf(datapoint *cloudwatch.Datapoint, statistic string) float64 {
+	switch statistic {
+	case cloudwatch.StatisticSum:
+		return awssdk.Float64Value(datapoint.Sum)
+	case cloudwatch.StatisticMinimum:
+		return awssdk.Float64Value(datapoint.Minimum)
+	case cloudwatch.StatisticMaximum:
+		return awssdk.Float64Value(datapoint.Maximum)
+	case cloudwatch.StatisticSampleCount:
+		return awssdk.Float64Value(datapoint.SampleCount)
+	}
+	return awssdk.Float64Value(datapoint.Average)
+}
+
+// downsample merges consecutive datapoints into at most max points,
+// keeping the meaning of the statistic: sums are added up, minimums and
+// maximums stay the extremes, and averages are averaged.
+func downsample(datapoints []*Datapoint, statistic string, max int) []*Datapoint {
+	if max <= 0 || len(datapoints) <= max {
+		return datapoints
+	}
+	size := int(math.Ceil(float64(len(datapoints)) / float64(max)))
+	result := []*Datapoint{}
+	for start := 0; start < len(datapoints); start += size {
+		end := start + size
+		if end > len(datapoints) {
+			end = len(datapoints)
+		}
+		bucket := datapoints[start:end]
+		merged := &Datapoint{Timestamp: bucket[0].Timestamp, Value: bucket[0].Value}
+		for _, datapoint := range bucket[1:] {
+			switch statistic {
+			case cloudwatch.StatisticMinimum:
+				merged.Value = math.Min(merged.Value, datapoint.Value)
+			case cloudwatch.StatisticMaximum:
+				merged.Value = math.Max(merged.Value, datapoint.Value)
+			default:
+				merged.Value += datapoint.Value
+			}
+		}
+		if statistic == cloudwatch.StatisticAverage {
+			merged.Value /= float64(len(bucket))
+		}
+		result = append(result, merged)
+	}
+	return result
+}
+
+type datapointsByTime []*Datapoint
+
+func (d datapointsByTime) Len() int           { return len(d) }
+func (d datapointsByTime) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d datapointsByTime) Less(i, j int) bool { return d[i].Timestamp.Before(d[j].Timestamp) }
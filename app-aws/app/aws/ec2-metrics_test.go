@@ -0,0 +1,81 @@
+package aws
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestEc2MetricQueryNormalize(t *testing.T) {
+	to := time.Date(2016, 1, 2, 15, 4, 5, 0, time.UTC)
+	actual := Ec2MetricQuery{Period: 90 * time.Second, From: to.Add(-30 * 24 * time.Hour), To: to}.normalize()
+
+	if actual.Name != "CPUUtilization" || actual.Statistic != "Average" {
+		t.Errorf("Expected CPUUtilization Average, but got %v %v", actual.Name, actual.Statistic)
+	}
+	// 30 days in 1440 datapoints needs 30 minutes at least
+	if actual.Period != 30*time.Minute {
+		t.Errorf("Expected %v, but got %v", 30*time.Minute, actual.Period)
+	}
+	expected := time.Date(2016, 1, 2, 15, 30, 0, 0, time.UTC)
+	if !actual.To.Equal(expected) {
+		t.Errorf("Expected %v, but got %v", expected, actual.To)
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	base := time.Date(2016, 1, 2, 0, 0, 0, 0, time.UTC)
+	datapoints := []*Datapoint{}
+	for idx, value := range []float64{1, 3, 2, 8, 5} {
+		datapoints = append(datapoints, &Datapoint{Timestamp: base.Add(time.Duration(idx) * time.Minute), Value: value})
+	}
+	for statistic, expected := range map[string][]float64{
+		"Average": []float64{2, 5, 5},
+		"Maximum": []float64{3, 8, 5},
+		"Minimum": []float64{1, 2, 5},
+		"Sum":     []float64{4, 10, 5},
+	} {
+		actual := downsample(datapoints, statistic, 3)
+		if len(actual) != len(expected) {
+			t.Errorf("Expected %v points, but got %v", len(expected), len(actual))
+			continue
+		}
+		for idx := range expected {
+			if actual[idx].Value != expected[idx] {
+				t.Errorf("Expected %v of %v, but got %v", expected[idx], statistic, actual[idx].Value)
+			}
+		}
+		if !actual[1].Timestamp.Equal(base.Add(2 * time.Minute)) {
+			t.Errorf("Expected %v, but got %v", base.Add(2*time.Minute), actual[1].Timestamp)
+		}
+	}
+}
+
+func TestEc2Metrics(t *testing.T) {
+	calls := 0
+	defer fakeQueryEndpoint(&cloudWatchCfg, func(action string, form url.Values) (int, string) {
+		calls++
+		if form.Get("Dimensions.member.1.Value") != "i-1" || form.Get("Statistics.member.1") != "Maximum" {
+			return http.StatusBadRequest, ""
+		}
+		return http.StatusOK, `<GetMetricStatisticsResponse><GetMetricStatisticsResult><Datapoints>` +
+			`<member><Timestamp>2016-01-02T00:05:00Z</Timestamp><Maximum>40</Maximum><Unit>Percent</Unit></member>` +
+			`<member><Timestamp>2016-01-02T00:00:00Z</Timestamp><Maximum>20</Maximum><Unit>Percent</Unit></member>` +
+			`</Datapoints></GetMetricStatisticsResult></GetMetricStatisticsResponse>`
+	})()
+
+	query := Ec2MetricQuery{Statistic: "Maximum", From: time.Date(2016, 1, 2, 0, 0, 0, 0, time.UTC)}
+	actual, err := Ec2Metrics("i-1", query)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if actual.Unit != "Percent" || len(actual.Datapoints) != 2 || actual.Datapoints[0].Value != 20 {
+		t.Errorf("Expected sorted datapoints in Percent, but got %v", actual)
+		return
+	}
+	if _, err = Ec2Metrics("i-1", query); err != nil || calls != 1 {
+		t.Errorf("Expected the second query to be cached, but got %v calls", calls)
+	}
+}
@@ -0,0 +1,237 @@
+package aws
+
+import (
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// Ec2Gateways represents internet and NAT gateways
+type Ec2Gateways struct {
+	Internet []*ec2.InternetGateway `json:"internet"`
+	Nat      []*ec2.NatGateway      `json:"nat"`
+}
+
+// NetworkNode represents a node of a network topology
+type NetworkNode struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Name    string `json:"name,omitempty"`
+	CIDR    string `json:"cidr,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+// NetworkEdge represents a relation between nodes of a network topology
+type NetworkEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`
+}
+
+// NetworkGraph represents a network topology as a graph
+type NetworkGraph struct {
+	Nodes []*NetworkNode `json:"nodes"`
+	Edges []*NetworkEdge `json:"edges"`
+}
+
+// Types of network nodes and edges
+const (
+	NodeVpc             = "vpc"
+	NodeSubnet          = "subnet"
+	NodeInstance        = "instance"
+	NodeSecurityGroup   = "security-group"
+	NodeInternetGateway = "internet-gateway"
+	NodeNatGateway      = "nat-gateway"
+	EdgeContains        = "contains"
+	EdgeAttached        = "attached"
+	EdgeUses            = "uses"
+	EdgeRoutes          = "routes"
+)
+
+func vpcFilter(name, vpcID string) []*ec2.Filter {
+	if vpcID == "" {
+		return nil
+	}
+	return []*ec2.Filter{&ec2.Filter{
+		Name:   awssdk.String(name),
+		Values: []*string{awssdk.String(vpcID)},
+	}}
+}
+
+// Ec2Vpcs returns VPCs
+func Ec2Vpcs(ids []string) (vpcs []*ec2.Vpc, e error) {
+	req := &ec2.DescribeVpcsInput{}
+	if len(ids) > 0 {
+		req.VpcIds = awssdk.StringSlice(ids)
+	}
+	res, err := ec2Client().DescribeVpcs(req)
+	if err != nil {
+		return nil, err
+	}
+	return append([]*ec2.Vpc{}, res.Vpcs...), nil
+}
+
+// Ec2Subnets returns subnets, which can be narrowed down to a VPC
+func Ec2Subnets(vpcID string) (subnets []*ec2.Subnet, e error) {
+	res, err := ec2Client().DescribeSubnets(&ec2.DescribeSubnetsInput{Filters: vpcFilter("vpc-id", vpcID)})
+	if err != nil {
+		return nil, err
+	}
+	return append([]*ec2.Subnet{}, res.Subnets...), nil
+}
+
+// Ec2RouteTables returns route tables, which can be narrowed down to a VPC
+func Ec2RouteTables(vpcID string) (tables []*ec2.RouteTable, e error) {
+	res, err := ec2Client().DescribeRouteTables(&ec2.DescribeRouteTablesInput{Filters: vpcFilter("vpc-id", vpcID)})
+	if err != nil {
+		return nil, err
+	}
+	return append([]*ec2.RouteTable{}, res.RouteTables...), nil
+}
+
+// Ec2GatewaysOf returns internet and NAT gateways, which can be narrowed down to a VPC
+func Ec2GatewaysOf(vpcID string) (gateways *Ec2Gateways, e error) {
+	igws, err := ec2Client().DescribeInternetGateways(&ec2.DescribeInternetGatewaysInput{
+		Filters: vpcFilter("attachment.vpc-id", vpcID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	gateways = &Ec2Gateways{Internet: append([]*ec2.InternetGateway{}, igws.InternetGateways...), Nat: []*ec2.NatGateway{}}
+	err = ec2Client().DescribeNatGatewaysPages(&ec2.DescribeNatGatewaysInput{
+		Filter: vpcFilter("vpc-id", vpcID),
+	}, func(res *ec2.DescribeNatGatewaysOutput, last bool) bool {
+		gateways.Nat = append(gateways.Nat, res.NatGateways...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return gateways, nil
+}
+
+// NetworkTopology returns a graph of VPC -> subnet -> instance with security groups,
+// and gateways which subnets route to. It can be narrowed down to a VPC.
+func NetworkTopology(vpcID string) (graph *NetworkGraph, e error) {
+	var ids []string
+	if vpcID != "" {
+		ids = []string{vpcID}
+	}
+	vpcs, err := Ec2Vpcs(ids)
+	if err != nil {
+		return nil, err
+	}
+	subnets, err := Ec2Subnets(vpcID)
+	if err != nil {
+		return nil, err
+	}
+	tables, err := Ec2RouteTables(vpcID)
+	if err != nil {
+		return nil, err
+	}
+	gateways, err := Ec2GatewaysOf(vpcID)
+	if err != nil {
+		return nil, err
+	}
+	query := Ec2InstanceQuery{AllPages: true}
+	if vpcID != "" {
+		query.VpcIDs = []string{vpcID}
+	}
+	instances, err := Ec2Instances(query)
+	if err != nil {
+		return nil, err
+	}
+	return networkGraph(vpcs, subnets, tables, gateways, instances.Instances), nil
+}
+
+func networkGraph(vpcs []*ec2.Vpc, subnets []*ec2.Subnet, tables []*ec2.RouteTable,
+	gateways *Ec2Gateways, instances []*ec2.Instance) *NetworkGraph {
+
+	graph := &NetworkGraph{Nodes: []*NetworkNode{}, Edges: []*NetworkEdge{}}
+	nodes := map[string]bool{}
+	node := func(n *NetworkNode) {
+		if !nodes[n.ID] {
+			nodes[n.ID] = true
+			graph.Nodes = append(graph.Nodes, n)
+		}
+	}
+	edge := func(source, target, kind string) {
+		if nodes[source] && nodes[target] {
+			graph.Edges = append(graph.Edges, &NetworkEdge{Source: source, Target: target, Type: kind})
+		}
+	}
+	for _, vpc := range vpcs {
+		node(&NetworkNode{ID: awssdk.StringValue(vpc.VpcId), Type: NodeVpc, Name: nameTag(vpc.Tags), CIDR: awssdk.StringValue(vpc.CidrBlock)})
+	}
+	for _, subnet := range subnets {
+		id := awssdk.StringValue(subnet.SubnetId)
+		node(&NetworkNode{ID: id, Type: NodeSubnet, Name: nameTag(subnet.Tags), CIDR: awssdk.StringValue(subnet.CidrBlock)})
+		edge(awssdk.StringValue(subnet.VpcId), id, EdgeContains)
+	}
+	for _, igw := range gateways.Internet {
+		id := awssdk.StringValue(igw.InternetGatewayId)
+		node(&NetworkNode{ID: id, Type: NodeInternetGateway, Name: nameTag(igw.Tags)})
+		for _, attachment := range igw.Attachments {
+			edge(id, awssdk.StringValue(attachment.VpcId), EdgeAttached)
+		}
+	}
+	for _, nat := range gateways.Nat {
+		id := awssdk.StringValue(nat.NatGatewayId)
+		node(&NetworkNode{ID: id, Type: NodeNatGateway, Name: nameTag(nat.Tags)})
+		edge(awssdk.StringValue(nat.SubnetId), id, EdgeContains)
+	}
+	for _, instance := range instances {
+		id := awssdk.StringValue(instance.InstanceId)
+		node(&NetworkNode{ID: id, Type: NodeInstance, Name: nameTag(instance.Tags), Address: awssdk.StringValue(instance.PrivateIpAddress)})
+		edge(awssdk.StringValue(instance.SubnetId), id, EdgeContains)
+		for _, group := range instance.SecurityGroups {
+			groupID := awssdk.StringValue(group.GroupId)
+			node(&NetworkNode{ID: groupID, Type: NodeSecurityGroup, Name: awssdk.StringValue(group.GroupName)})
+			edge(id, groupID, EdgeUses)
+		}
+	}
+	routeEdges(tables, subnets, edge)
+	return graph
+}
+
+// routeEdges connects subnets to gateways which their route tables route to.
+// Subnets without explicit associations follow the main route table of their VPC.
+func routeEdges(tables []*ec2.RouteTable, subnets []*ec2.Subnet, edge func(source, target, kind string)) {
+	associated := map[string]*ec2.RouteTable{}
+	main := map[string]*ec2.RouteTable{}
+	for _, table := range tables {
+		for _, association := range table.Associations {
+			if awssdk.BoolValue(association.Main) {
+				main[awssdk.StringValue(table.VpcId)] = table
+			}
+			if association.SubnetId != nil {
+				associated[awssdk.StringValue(association.SubnetId)] = table
+			}
+		}
+	}
+	for _, subnet := range subnets {
+		id := awssdk.StringValue(subnet.SubnetId)
+		table, found := associated[id]
+		if !found {
+			if table, found = main[awssdk.StringValue(subnet.VpcId)]; !found {
+				continue
+			}
+		}
+		for _, route := range table.Routes {
+			switch {
+			case route.NatGatewayId != nil:
+				edge(id, awssdk.StringValue(route.NatGatewayId), EdgeRoutes)
+			case route.GatewayId != nil && awssdk.StringValue(route.GatewayId) != "local":
+				edge(id, awssdk.StringValue(route.GatewayId), EdgeRoutes)
+			}
+		}
+	}
+}
+
+func nameTag(tags []*ec2.Tag) string {
+	for _, tag := range tags {
+		if awssdk.StringValue(tag.Key) == "Name" {
+			return awssdk.StringValue(tag.Value)
+		}
+	}
+	return ""
+}
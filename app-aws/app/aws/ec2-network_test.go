@@ -0,0 +1,95 @@
+package aws
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestNetworkGraph(t *testing.T) {
+	vpcs := []*ec2.Vpc{&ec2.Vpc{VpcId: awssdk.String("vpc-1"), CidrBlock: awssdk.String("10.0.0.0/16")}}
+	subnets := []*ec2.Subnet{
+		&ec2.Subnet{SubnetId: awssdk.String("subnet-public"), VpcId: awssdk.String("vpc-1")},
+		&ec2.Subnet{SubnetId: awssdk.String("subnet-private"), VpcId: awssdk.String("vpc-1")},
+	}
+	tables := []*ec2.RouteTable{
+		&ec2.RouteTable{
+			VpcId:        awssdk.String("vpc-1"),
+			Associations: []*ec2.RouteTableAssociation{&ec2.RouteTableAssociation{Main: awssdk.Bool(true)}},
+			Routes: []*ec2.Route{
+				&ec2.Route{GatewayId: awssdk.String("local")},
+				&ec2.Route{NatGatewayId: awssdk.String("nat-1")},
+			},
+		},
+		&ec2.RouteTable{
+			VpcId:        awssdk.String("vpc-1"),
+			Associations: []*ec2.RouteTableAssociation{&ec2.RouteTableAssociation{SubnetId: awssdk.String("subnet-public")}},
+			Routes:       []*ec2.Route{&ec2.Route{GatewayId: awssdk.String("igw-1")}},
+		},
+	}
+	gateways := &Ec2Gateways{
+		Internet: []*ec2.InternetGateway{&ec2.InternetGateway{
+			InternetGatewayId: awssdk.String("igw-1"),
+			Attachments:       []*ec2.InternetGatewayAttachment{&ec2.InternetGatewayAttachment{VpcId: awssdk.String("vpc-1")}},
+		}},
+		Nat: []*ec2.NatGateway{&ec2.NatGateway{NatGatewayId: awssdk.String("nat-1"), SubnetId: awssdk.String("subnet-public")}},
+	}
+	instances := []*ec2.Instance{&ec2.Instance{
+		InstanceId:     awssdk.String("i-1"),
+		SubnetId:       awssdk.String("subnet-private"),
+		SecurityGroups: []*ec2.GroupIdentifier{&ec2.GroupIdentifier{GroupId: awssdk.String("sg-1")}},
+		Tags:           []*ec2.Tag{&ec2.Tag{Key: awssdk.String("Name"), Value: awssdk.String("app")}},
+	}}
+	actual := networkGraph(vpcs, subnets, tables, gateways, instances)
+
+	if len(actual.Nodes) != 7 {
+		t.Errorf("Expected %v nodes, but got %v", 7, len(actual.Nodes))
+	}
+	edges := map[string]bool{}
+	for _, edge := range actual.Edges {
+		edges[edge.Source+" "+edge.Type+" "+edge.Target] = true
+	}
+	for _, expected := range []string{
+		"vpc-1 contains subnet-public",
+		"vpc-1 contains subnet-private",
+		"igw-1 attached vpc-1",
+		"subnet-public contains nat-1",
+		"subnet-private contains i-1",
+		"i-1 uses sg-1",
+		"subnet-public routes igw-1",
+		"subnet-private routes nat-1",
+	} {
+		if !edges[expected] {
+			t.Errorf("Expected an edge %q, but got %v", expected, edges)
+		}
+	}
+	if len(actual.Edges) != 8 {
+		t.Errorf("Expected %v edges, but got %v", 8, len(actual.Edges))
+	}
+}
+
+func TestEc2NetworkWithNothing(t *testing.T) {
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		return http.StatusOK, `<` + action + `Response><requestId>req</requestId></` + action + `Response>`
+	})()
+
+	vpcs, err := Ec2Vpcs(nil)
+	if err != nil || vpcs == nil {
+		t.Errorf("Expected an empty list of VPCs, but got %v, %v", vpcs, err)
+	}
+	subnets, err := Ec2Subnets("vpc-1")
+	if err != nil || subnets == nil {
+		t.Errorf("Expected an empty list of subnets, but got %v, %v", subnets, err)
+	}
+	tables, err := Ec2RouteTables("vpc-1")
+	if err != nil || tables == nil {
+		t.Errorf("Expected an empty list of route tables, but got %v, %v", tables, err)
+	}
+	gateways, err := Ec2GatewaysOf("vpc-1")
+	if err != nil || gateways.Internet == nil || gateways.Nat == nil {
+		t.Errorf("Expected empty lists of gateways, but got %v, %v", gateways, err)
+	}
+}
@@ -0,0 +1,230 @@
+package aws
+
+import (
+	"errors"
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// SensitivePorts are ports which should not be open to the world
+var SensitivePorts = []int64{20, 21, 22, 23, 445, 1433, 1521, 3306, 3389, 5432, 5900, 6379, 9200, 11211, 27017}
+
+// ErrNoSuchSecurityGroup means that the security group does not exist
+var ErrNoSuchSecurityGroup = errors.New("the security group does not exist")
+
+// Ec2SecurityGroup represents a security group with instances which use it
+type Ec2SecurityGroup struct {
+	*ec2.SecurityGroup
+	InstanceIDs []string `json:"InstanceIds"`
+}
+
+// Ec2Rule represents a security group rule to add or revoke
+type Ec2Rule struct {
+	Egress        bool   `json:"egress"`
+	Protocol      string `json:"protocol"`
+	FromPort      int64  `json:"fromPort"`
+	ToPort        int64  `json:"toPort"`
+	CidrIP        string `json:"cidr,omitempty"`
+	SourceGroupID string `json:"sourceGroupId,omitempty"`
+	Description   string `json:"description,omitempty"`
+}
+
+// Ec2Exposure represents an ingress rule which opens sensitive ports to the world
+type Ec2Exposure struct {
+	GroupID     string   `json:"groupId"`
+	GroupName   string   `json:"groupName"`
+	Protocol    string   `json:"protocol"`
+	FromPort    int64    `json:"fromPort"`
+	ToPort      int64    `json:"toPort"`
+	CidrIP      string   `json:"cidr"`
+	Ports       []int64  `json:"ports"`
+	InstanceIDs []string `json:"instanceIds"`
+}
+
+// Ec2SecurityGroups returns security groups with instances which use them
+func Ec2SecurityGroups(ids []string) (groups []*Ec2SecurityGroup, e error) {
+	req := &ec2.DescribeSecurityGroupsInput{}
+	if len(ids) > 0 {
+		req.GroupIds = awssdk.StringSlice(ids)
+	}
+	found := []*ec2.SecurityGroup{}
+	err := ec2Client().DescribeSecurityGroupsPages(req, func(res *ec2.DescribeSecurityGroupsOutput, last bool) bool {
+		found = append(found, res.SecurityGroups...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	users, err := ec2SecurityGroupUsers()
+	if err != nil {
+		return nil, err
+	}
+	groups = []*Ec2SecurityGroup{}
+	for _, group := range found {
+		instances := users[awssdk.StringValue(group.GroupId)]
+		if instances == nil {
+			instances = []string{}
+		}
+		groups = append(groups, &Ec2SecurityGroup{SecurityGroup: group, InstanceIDs: instances})
+	}
+	return groups, nil
+}
+
+// Ec2SecurityGroupByID returns a specified security group, or nil when it does not exist
+func Ec2SecurityGroupByID(id string) (group *Ec2SecurityGroup, e error) {
+	groups, err := Ec2SecurityGroups([]string{id})
+	if ec2GroupNotFound(err, id) {
+		return nil, nil
+	}
+	if err != nil || len(groups) == 0 {
+		return nil, err
+	}
+	return groups[0], nil
+}
+
+// ec2SecurityGroupUsers maps security group IDs to instance IDs which use them
+func ec2SecurityGroupUsers() (users map[string][]string, e error) {
+	page, err := Ec2Instances(Ec2InstanceQuery{AllPages: true})
+	if err != nil {
+		return nil, err
+	}
+	users = map[string][]string{}
+	for _, instance := range page.Instances {
+		for _, group := range instance.SecurityGroups {
+			id := awssdk.StringValue(group.GroupId)
+			users[id] = append(users[id], awssdk.StringValue(instance.InstanceId))
+		}
+	}
+	return users, nil
+}
+
+// Ec2AddRule authorizes an ingress or egress rule of a specified security group
+func Ec2AddRule(id string, rule Ec2Rule) error {
+	permissions := []*ec2.IpPermission{rule.permission()}
+	if rule.Egress {
+		_, err := ec2Client().AuthorizeSecurityGroupEgress(&ec2.AuthorizeSecurityGroupEgressInput{
+			GroupId:       awssdk.String(id),
+			IpPermissions: permissions,
+		})
+		return ec2RuleError(err, id)
+	}
+	_, err := ec2Client().AuthorizeSecurityGroupIngress(&ec2.AuthorizeSecurityGroupIngressInput{
+		GroupId:       awssdk.String(id),
+		IpPermissions: permissions,
+	})
+	return ec2RuleError(err, id)
+}
+
+// Ec2RevokeRule revokes an ingress or egress rule of a specified security group
+func Ec2RevokeRule(id string, rule Ec2Rule) error {
+	permissions := []*ec2.IpPermission{rule.permission()}
+	if rule.Egress {
+		_, err := ec2Client().RevokeSecurityGroupEgress(&ec2.RevokeSecurityGroupEgressInput{
+			GroupId:       awssdk.String(id),
+			IpPermissions: permissions,
+		})
+		return ec2RuleError(err, id)
+	}
+	_, err := ec2Client().RevokeSecurityGroupIngress(&ec2.RevokeSecurityGroupIngressInput{
+		GroupId:       awssdk.String(id),
+		IpPermissions: permissions,
+	})
+	return ec2RuleError(err, id)
+}
+
+// ec2RuleError tells that the group does not exist with ErrNoSuchSecurityGroup
+func ec2RuleError(err error, id string) error {
+	if ec2GroupNotFound(err, id) {
+		return ErrNoSuchSecurityGroup
+	}
+	return err
+}
+
+// ec2GroupNotFound tells if the error says that the group does not exist,
+// not the source group of a rule
+func ec2GroupNotFound(err error, id string) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == "InvalidGroup.NotFound" && strings.Contains(aerr.Message(), id)
+}
+
+func (rule Ec2Rule) permission() *ec2.IpPermission {
+	permission := &ec2.IpPermission{IpProtocol: awssdk.String(rule.Protocol)}
+	if rule.Protocol != "-1" {
+		permission.FromPort = awssdk.Int64(rule.FromPort)
+		permission.ToPort = awssdk.Int64(rule.ToPort)
+	}
+	var description *string
+	if rule.Description != "" {
+		description = awssdk.String(rule.Description)
+	}
+	switch {
+	case strings.Contains(rule.CidrIP, ":"):
+		permission.Ipv6Ranges = []*ec2.Ipv6Range{&ec2.Ipv6Range{CidrIpv6: awssdk.String(rule.CidrIP), Description: description}}
+	case rule.CidrIP != "":
+		permission.IpRanges = []*ec2.IpRange{&ec2.IpRange{CidrIp: awssdk.String(rule.CidrIP), Description: description}}
+	case rule.SourceGroupID != "":
+		permission.UserIdGroupPairs = []*ec2.UserIdGroupPair{&ec2.UserIdGroupPair{GroupId: awssdk.String(rule.SourceGroupID), Description: description}}
+	}
+	return permission
+}
+
+// Ec2ExposureReport finds ingress rules which open any of the ports to 0.0.0.0/0 or ::/0
+func Ec2ExposureReport(ports []int64) (exposures []*Ec2Exposure, e error) {
+	groups, err := Ec2SecurityGroups(nil)
+	if err != nil {
+		return nil, err
+	}
+	return ec2Exposures(groups, ports), nil
+}
+
+func ec2Exposures(groups []*Ec2SecurityGroup, ports []int64) []*Ec2Exposure {
+	exposures := []*Ec2Exposure{}
+	for _, group := range groups {
+		for _, permission := range group.IpPermissions {
+			protocol := awssdk.StringValue(permission.IpProtocol)
+			from, to := awssdk.Int64Value(permission.FromPort), awssdk.Int64Value(permission.ToPort)
+			switch protocol {
+			case "-1":
+				from, to = 0, 65535
+			case "tcp", "udp", "6", "17":
+			default:
+				continue
+			}
+			exposed := []int64{}
+			for _, port := range ports {
+				if from <= port && port <= to {
+					exposed = append(exposed, port)
+				}
+			}
+			if len(exposed) == 0 {
+				continue
+			}
+			cidrs := []string{}
+			for _, r := range permission.IpRanges {
+				cidrs = append(cidrs, awssdk.StringValue(r.CidrIp))
+			}
+			for _, r := range permission.Ipv6Ranges {
+				cidrs = append(cidrs, awssdk.StringValue(r.CidrIpv6))
+			}
+			for _, cidr := range cidrs {
+				if cidr != "0.0.0.0/0" && cidr != "::/0" {
+					continue
+				}
+				exposures = append(exposures, &Ec2Exposure{
+					GroupID:     awssdk.StringValue(group.GroupId),
+					GroupName:   awssdk.StringValue(group.GroupName),
+					Protocol:    protocol,
+					FromPort:    from,
+					ToPort:      to,
+					CidrIP:      cidr,
+					Ports:       exposed,
+					InstanceIDs: group.InstanceIDs,
+				})
+			}
+		}
+	}
+	return exposures
+}
@@ -0,0 +1,148 @@
+package aws
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func TestEc2Exposures(t *testing.T) {
+	groups := []*Ec2SecurityGroup{
+		&Ec2SecurityGroup{
+			SecurityGroup: &ec2.SecurityGroup{
+				GroupId:   awssdk.String("sg-1"),
+				GroupName: awssdk.String("web"),
+				IpPermissions: []*ec2.IpPermission{
+					&ec2.IpPermission{
+						IpProtocol: awssdk.String("tcp"),
+						FromPort:   awssdk.Int64(443),
+						ToPort:     awssdk.Int64(443),
+						IpRanges:   []*ec2.IpRange{&ec2.IpRange{CidrIp: awssdk.String("0.0.0.0/0")}},
+					},
+					&ec2.IpPermission{
+						IpProtocol: awssdk.String("tcp"),
+						FromPort:   awssdk.Int64(0),
+						ToPort:     awssdk.Int64(3400),
+						IpRanges:   []*ec2.IpRange{&ec2.IpRange{CidrIp: awssdk.String("0.0.0.0/0")}},
+					},
+					&ec2.IpPermission{
+						IpProtocol: awssdk.String("tcp"),
+						FromPort:   awssdk.Int64(22),
+						ToPort:     awssdk.Int64(22),
+						IpRanges:   []*ec2.IpRange{&ec2.IpRange{CidrIp: awssdk.String("10.0.0.0/8")}},
+					},
+				},
+			},
+			InstanceIDs: []string{"i-1"},
+		},
+		&Ec2SecurityGroup{
+			SecurityGroup: &ec2.SecurityGroup{
+				GroupId: awssdk.String("sg-2"),
+				IpPermissions: []*ec2.IpPermission{
+					&ec2.IpPermission{
+						IpProtocol: awssdk.String("-1"),
+						Ipv6Ranges: []*ec2.Ipv6Range{&ec2.Ipv6Range{CidrIpv6: awssdk.String("::/0")}},
+					},
+					&ec2.IpPermission{
+						IpProtocol: awssdk.String("icmp"),
+						FromPort:   awssdk.Int64(8),
+						ToPort:     awssdk.Int64(22),
+						IpRanges:   []*ec2.IpRange{&ec2.IpRange{CidrIp: awssdk.String("0.0.0.0/0")}},
+					},
+				},
+			},
+		},
+	}
+	actual := ec2Exposures(groups, []int64{22, 3389})
+	if len(actual) != 2 {
+		t.Errorf("Expected %v exposures, but got %v", 2, len(actual))
+		return
+	}
+	if actual[0].GroupID != "sg-1" || !reflect.DeepEqual(actual[0].Ports, []int64{22, 3389}) {
+		t.Errorf("Expected sg-1 to expose [22 3389], but got %v %v", actual[0].GroupID, actual[0].Ports)
+	}
+	if actual[1].GroupID != "sg-2" || actual[1].CidrIP != "::/0" {
+		t.Errorf("Expected sg-2 to expose to ::/0, but got %v %v", actual[1].GroupID, actual[1].CidrIP)
+	}
+}
+
+// fakeEc2Rules answers rule changes of sg-1 and describes it without instances,
+// and tells the last form of a rule change
+func fakeEc2Rules(changed *url.Values) func() {
+	return fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		switch action {
+		case "AuthorizeSecurityGroupIngress", "AuthorizeSecurityGroupEgress",
+			"RevokeSecurityGroupIngress", "RevokeSecurityGroupEgress":
+			if form.Get("GroupId") != "sg-1" {
+				return http.StatusBadRequest, `<Response><Errors><Error><Code>InvalidGroup.NotFound</Code>` +
+					`<Message>The security group '` + form.Get("GroupId") + `' does not exist</Message>` +
+					`</Error></Errors><RequestID>req</RequestID></Response>`
+			}
+			*changed = form
+			return http.StatusOK, `<` + action + `Response><return>true</return></` + action + `Response>`
+		case "DescribeSecurityGroups":
+			return http.StatusOK, `<DescribeSecurityGroupsResponse><securityGroupInfo><item>` +
+				`<groupId>sg-1</groupId></item></securityGroupInfo></DescribeSecurityGroupsResponse>`
+		case "DescribeInstances":
+			return http.StatusOK, `<DescribeInstancesResponse><reservationSet/></DescribeInstancesResponse>`
+		}
+		return http.StatusBadRequest, ec2Error("InvalidAction")
+	})
+}
+
+func TestEc2AddRule(t *testing.T) {
+	var changed url.Values
+	defer fakeEc2Rules(&changed)()
+
+	rule := Ec2Rule{Protocol: "tcp", FromPort: 443, ToPort: 443, CidrIP: "10.0.0.0/8", Description: "https"}
+	if err := Ec2AddRule("sg-1", rule); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	expected := map[string]string{
+		"Action":                                 "AuthorizeSecurityGroupIngress",
+		"IpPermissions.1.IpProtocol":             "tcp",
+		"IpPermissions.1.FromPort":               "443",
+		"IpPermissions.1.ToPort":                 "443",
+		"IpPermissions.1.IpRanges.1.CidrIp":      "10.0.0.0/8",
+		"IpPermissions.1.IpRanges.1.Description": "https",
+	}
+	for key, value := range expected {
+		if changed.Get(key) != value {
+			t.Errorf("Expected %v to be %v, but got %v", key, value, changed.Get(key))
+		}
+	}
+	rule = Ec2Rule{Egress: true, Protocol: "-1", SourceGroupID: "sg-2"}
+	if err := Ec2AddRule("sg-1", rule); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if changed.Get("Action") != "AuthorizeSecurityGroupEgress" || changed.Get("IpPermissions.1.Groups.1.GroupId") != "sg-2" ||
+		changed.Get("IpPermissions.1.FromPort") != "" {
+		t.Errorf("Expected an egress rule to sg-2 for all ports, but got %v", changed)
+	}
+	if err := Ec2AddRule("sg-unknown", rule); err != ErrNoSuchSecurityGroup {
+		t.Errorf("Expected %v, but got %v", ErrNoSuchSecurityGroup, err)
+	}
+}
+
+func TestEc2RevokeRule(t *testing.T) {
+	var changed url.Values
+	defer fakeEc2Rules(&changed)()
+
+	rule := Ec2Rule{Protocol: "tcp", FromPort: 22, ToPort: 22, CidrIP: "::/0"}
+	if err := Ec2RevokeRule("sg-1", rule); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if changed.Get("Action") != "RevokeSecurityGroupIngress" || changed.Get("IpPermissions.1.Ipv6Ranges.1.CidrIpv6") != "::/0" {
+		t.Errorf("Expected the ingress rule from ::/0 to be revoked, but got %v", changed)
+	}
+	if err := Ec2RevokeRule("sg-unknown", rule); err != ErrNoSuchSecurityGroup {
+		t.Errorf("Expected %v, but got %v", ErrNoSuchSecurityGroup, err)
+	}
+}
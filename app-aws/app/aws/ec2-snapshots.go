@@ -0,0 +1,173 @@
+package aws
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// Ec2SnapshotResult represents a result of an operation on a snapshot
+type Ec2SnapshotResult struct {
+	SnapshotID string `json:"id"`
+	VolumeID   string `json:"volumeId,omitempty"`
+	Success    bool   `json:"success"`
+	Message    string `json:"message,omitempty"`
+}
+
+// Ec2RetentionPolicy keeps the latest snapshot of each of the recent days and weeks
+type Ec2RetentionPolicy struct {
+	Daily  int `json:"daily"`
+	Weekly int `json:"weekly"`
+}
+
+// Ec2RetentionPlan represents snapshots to keep and to prune under a retention policy
+type Ec2RetentionPlan struct {
+	Policy  Ec2RetentionPolicy   `json:"policy"`
+	Keep    []*ec2.Snapshot      `json:"keep"`
+	Prune   []*ec2.Snapshot      `json:"prune"`
+	Results []*Ec2SnapshotResult `json:"results,omitempty"`
+}
+
+// Ec2Snapshots returns snapshots owned by the account, which were started
+// before the specified time unless it's zero. They can be narrowed down to a volume.
+func Ec2Snapshots(volumeID string, before time.Time) (snapshots []*ec2.Snapshot, e error) {
+	req := &ec2.DescribeSnapshotsInput{OwnerIds: []*string{awssdk.String("self")}}
+	if volumeID != "" {
+		req.Filters = []*ec2.Filter{&ec2.Filter{
+			Name:   awssdk.String("volume-id"),
+			Values: []*string{awssdk.String(volumeID)},
+		}}
+	}
+	snapshots = []*ec2.Snapshot{}
+	err := ec2Client().DescribeSnapshotsPages(req, func(res *ec2.DescribeSnapshotsOutput, last bool) bool {
+		for _, snapshot := range res.Snapshots {
+			if before.IsZero() || awssdk.TimeValue(snapshot.StartTime).Before(before) {
+				snapshots = append(snapshots, snapshot)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// Ec2SnapshotVolumes creates snapshots of volumes one by one and reports results per volume.
+// It stops when the context is canceled, and tells how many volumes it has done with progress.
+func Ec2SnapshotVolumes(ctx context.Context, volumes []*ec2.Volume, description string, progress func(done, total int)) ([]*Ec2SnapshotResult, error) {
+	results := []*Ec2SnapshotResult{}
+	for idx, volume := range volumes {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		result := &Ec2SnapshotResult{VolumeID: awssdk.StringValue(volume.VolumeId)}
+		snapshot, err := ec2Client().CreateSnapshotWithContext(ctx, &ec2.CreateSnapshotInput{
+			VolumeId:    volume.VolumeId,
+			Description: awssdk.String(description),
+		})
+		if err != nil {
+			result.Message = err.Error()
+		} else {
+			result.SnapshotID = awssdk.StringValue(snapshot.SnapshotId)
+			result.Success = true
+		}
+		results = append(results, result)
+		progress(idx+1, len(volumes))
+	}
+	return results, nil
+}
+
+// Ec2DeleteSnapshot deletes a specified snapshot
+func Ec2DeleteSnapshot(id string) error {
+	_, err := ec2Client().DeleteSnapshot(&ec2.DeleteSnapshotInput{SnapshotId: awssdk.String(id)})
+	return err
+}
+
+// Ec2DeleteSnapshots deletes snapshots one by one and reports results per snapshot.
+// It stops when the context is canceled, and tells how many snapshots it has done with progress.
+func Ec2DeleteSnapshots(ctx context.Context, snapshots []*ec2.Snapshot, progress func(done, total int)) ([]*Ec2SnapshotResult, error) {
+	results := []*Ec2SnapshotResult{}
+	for idx, snapshot := range snapshots {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		result := &Ec2SnapshotResult{
+			SnapshotID: awssdk.StringValue(snapshot.SnapshotId),
+			VolumeID:   awssdk.StringValue(snapshot.VolumeId),
+		}
+		_, err := ec2Client().DeleteSnapshotWithContext(ctx, &ec2.DeleteSnapshotInput{SnapshotId: snapshot.SnapshotId})
+		if err != nil {
+			result.Message = err.Error()
+		} else {
+			result.Success = true
+		}
+		results = append(results, result)
+		progress(idx+1, len(snapshots))
+	}
+	return results, nil
+}
+
+// Ec2Retention computes which snapshots would be pruned under the policy
+func Ec2Retention(volumeID string, policy Ec2RetentionPolicy) (plan *Ec2RetentionPlan, e error) {
+	snapshots, err := Ec2Snapshots(volumeID, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return ec2RetentionPlan(snapshots, policy), nil
+}
+
+// ec2RetentionPlan keeps, for each volume, the latest completed snapshot of
+// each of the latest N days and M ISO weeks which have any snapshots.
+// Snapshots which have not completed yet are always kept.
+func ec2RetentionPlan(snapshots []*ec2.Snapshot, policy Ec2RetentionPolicy) *Ec2RetentionPlan {
+	plan := &Ec2RetentionPlan{Policy: policy, Keep: []*ec2.Snapshot{}, Prune: []*ec2.Snapshot{}}
+
+	sorted := make([]*ec2.Snapshot, len(snapshots))
+	copy(sorted, snapshots)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return awssdk.TimeValue(sorted[i].StartTime).After(awssdk.TimeValue(sorted[j].StartTime))
+	})
+	type period struct {
+		volume string
+		year   int
+		number int
+	}
+	days := map[period]bool{}
+	weeks := map[period]bool{}
+	dayCount := map[string]int{}
+	weekCount := map[string]int{}
+
+	for _, snapshot := range sorted {
+		if awssdk.StringValue(snapshot.State) != ec2.SnapshotStateCompleted {
+			plan.Keep = append(plan.Keep, snapshot)
+			continue
+		}
+		volume := awssdk.StringValue(snapshot.VolumeId)
+		started := awssdk.TimeValue(snapshot.StartTime).UTC()
+		keep := false
+
+		day := period{volume, started.Year(), started.YearDay()}
+		if !days[day] && dayCount[volume] < policy.Daily {
+			days[day] = true
+			dayCount[volume]++
+			keep = true
+		}
+		year, number := started.ISOWeek()
+		week := period{volume, year, number}
+		if !weeks[week] && weekCount[volume] < policy.Weekly {
+			weeks[week] = true
+			weekCount[volume]++
+			keep = true
+		}
+		if keep {
+			plan.Keep = append(plan.Keep, snapshot)
+		} else {
+			plan.Prune = append(plan.Prune, snapshot)
+		}
+	}
+	return plan
+}
@@ -0,0 +1,101 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func snapshot(id, volume, state string, started time.Time) *ec2.Snapshot {
+	return &ec2.Snapshot{
+		SnapshotId: awssdk.String(id),
+		VolumeId:   awssdk.String(volume),
+		State:      awssdk.String(state),
+		StartTime:  awssdk.Time(started),
+	}
+}
+
+func snapshotIDs(snapshots []*ec2.Snapshot) map[string]bool {
+	ids := map[string]bool{}
+	for _, snapshot := range snapshots {
+		ids[*snapshot.SnapshotId] = true
+	}
+	return ids
+}
+
+func TestEc2RetentionPlan(t *testing.T) {
+	// 2016-01-04 is a Monday
+	monday := time.Date(2016, 1, 4, 12, 0, 0, 0, time.UTC)
+	snapshots := []*ec2.Snapshot{
+		snapshot("snap-a", "vol-1", "completed", monday.AddDate(0, 0, 2)),
+		snapshot("snap-b", "vol-1", "completed", monday.AddDate(0, 0, 2).Add(-time.Hour)),
+		snapshot("snap-c", "vol-1", "completed", monday.AddDate(0, 0, 1)),
+		snapshot("snap-d", "vol-1", "completed", monday),
+		snapshot("snap-e", "vol-1", "completed", monday.AddDate(0, 0, -1)),
+		snapshot("snap-f", "vol-1", "completed", monday.AddDate(0, 0, -2)),
+		snapshot("snap-g", "vol-1", "completed", monday.AddDate(0, 0, -14)),
+		snapshot("snap-h", "vol-1", "pending", monday.AddDate(0, 0, -30)),
+		snapshot("snap-i", "vol-2", "completed", monday.AddDate(0, 0, -30)),
+	}
+	actual := ec2RetentionPlan(snapshots, Ec2RetentionPolicy{Daily: 2, Weekly: 2})
+
+	// daily: snap-a, snap-c / weekly: snap-a (this week), snap-e (last week)
+	// snap-h is still pending and snap-i belongs to another volume
+	keep := snapshotIDs(actual.Keep)
+	for _, id := range []string{"snap-a", "snap-c", "snap-e", "snap-h", "snap-i"} {
+		if !keep[id] {
+			t.Errorf("Expected %v to be kept, but got %v", id, keep)
+		}
+	}
+	prune := snapshotIDs(actual.Prune)
+	for _, id := range []string{"snap-b", "snap-d", "snap-f", "snap-g"} {
+		if !prune[id] {
+			t.Errorf("Expected %v to be pruned, but got %v", id, prune)
+		}
+	}
+	if len(actual.Keep)+len(actual.Prune) != len(snapshots) {
+		t.Errorf("Expected %v snapshots in total, but got %v", len(snapshots), len(actual.Keep)+len(actual.Prune))
+	}
+}
+
+func TestEc2DeleteSnapshotsStopsWhenCanceled(t *testing.T) {
+	deleted := []string{}
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		deleted = append(deleted, form.Get("SnapshotId"))
+		if form.Get("SnapshotId") == "snap-2" {
+			return http.StatusBadRequest, ec2Error("InvalidSnapshot.InUse")
+		}
+		return http.StatusOK, `<DeleteSnapshotResponse><return>true</return></DeleteSnapshotResponse>`
+	})()
+
+	base := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	snapshots := []*ec2.Snapshot{
+		snapshot("snap-1", "vol-1", "completed", base),
+		snapshot("snap-2", "vol-1", "completed", base),
+		snapshot("snap-3", "vol-1", "completed", base),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	progress := []int{}
+	actual, err := Ec2DeleteSnapshots(ctx, snapshots, func(done, total int) {
+		progress = append(progress, done)
+		if done == 2 {
+			cancel()
+		}
+	})
+	if err != context.Canceled {
+		t.Errorf("Expected %v, but got %v", context.Canceled, err)
+	}
+	if len(deleted) != 2 || len(actual) != 2 || len(progress) != 2 {
+		t.Errorf("Expected 2 snapshots to be done before canceled, but got %v", deleted)
+		return
+	}
+	if !actual[0].Success || actual[1].Success || actual[1].Message == "" {
+		t.Errorf("Expected results per snapshot, but got %v and %v", actual[0], actual[1])
+	}
+}
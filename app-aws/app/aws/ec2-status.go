@@ -0,0 +1,119 @@
+package aws
+
+import (
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// Ec2Health represents reachability checks and scheduled events of an instance
+type Ec2Health struct {
+	InstanceID       string                     `json:"instanceId"`
+	AvailabilityZone string                     `json:"availabilityZone"`
+	State            string                     `json:"state"`
+	System           string                     `json:"system"`
+	Instance         string                     `json:"instance"`
+	Impaired         bool                       `json:"impaired"`
+	Events           []*ec2.InstanceStatusEvent `json:"events"`
+}
+
+// Ec2HealthSummary represents health of all the instances at a glance
+type Ec2HealthSummary struct {
+	Count        int          `json:"count"`
+	OK           int          `json:"ok"`
+	Impaired     int          `json:"impaired"`
+	Initializing int          `json:"initializing"`
+	Stopped      int          `json:"stopped"`
+	Unknown      int          `json:"unknown"`
+	Scheduled    int          `json:"scheduled"`
+	Instances    []*Ec2Health `json:"instances"`
+}
+
+// Ec2Status returns health of a specified instance
+func Ec2Status(id string) (health *Ec2Health, e error) {
+	healths, err := ec2Statuses([]string{id})
+	if err != nil || len(healths) == 0 {
+		return nil, err
+	}
+	return healths[0], nil
+}
+
+// Ec2StatusSummary summarizes health of all the instances. Only the instances
+// which are impaired or have scheduled events are listed, as they need attention.
+func Ec2StatusSummary() (summary *Ec2HealthSummary, e error) {
+	healths, err := ec2Statuses(nil)
+	if err != nil {
+		return nil, err
+	}
+	return ec2HealthSummary(healths), nil
+}
+
+func ec2Statuses(ids []string) (healths []*Ec2Health, e error) {
+	req := &ec2.DescribeInstanceStatusInput{IncludeAllInstances: awssdk.Bool(true)}
+	if len(ids) > 0 {
+		req.InstanceIds = awssdk.StringSlice(ids)
+	}
+	healths = []*Ec2Health{}
+	err := ec2Client().DescribeInstanceStatusPages(req, func(res *ec2.DescribeInstanceStatusOutput, last bool) bool {
+		for _, status := range res.InstanceStatuses {
+			healths = append(healths, ec2HealthOf(status))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return healths, nil
+}
+
+func ec2HealthOf(status *ec2.InstanceStatus) *Ec2Health {
+	health := &Ec2Health{
+		InstanceID:       awssdk.StringValue(status.InstanceId),
+		AvailabilityZone: awssdk.StringValue(status.AvailabilityZone),
+		Events:           []*ec2.InstanceStatusEvent{},
+	}
+	if status.InstanceState != nil {
+		health.State = awssdk.StringValue(status.InstanceState.Name)
+	}
+	if status.SystemStatus != nil {
+		health.System = awssdk.StringValue(status.SystemStatus.Status)
+	}
+	if status.InstanceStatus != nil {
+		health.Instance = awssdk.StringValue(status.InstanceStatus.Status)
+	}
+	health.Impaired = health.System == ec2.SummaryStatusImpaired || health.Instance == ec2.SummaryStatusImpaired
+	for _, event := range status.Events {
+		// events which have already been done are described as "[Completed] ..."
+		if !strings.HasPrefix(awssdk.StringValue(event.Description), "[Completed]") {
+			health.Events = append(health.Events, event)
+		}
+	}
+	return health
+}
+
+func ec2HealthSummary(healths []*Ec2Health) *Ec2HealthSummary {
+	summary := &Ec2HealthSummary{Count: len(healths), Instances: []*Ec2Health{}}
+	for _, health := range healths {
+		switch {
+		case health.State == ec2.InstanceStateNameStopped || health.State == ec2.InstanceStateNameStopping:
+			// their checks are not applicable, which does not mean anything is wrong
+			summary.Stopped++
+		case health.Impaired:
+			summary.Impaired++
+		case health.System == ec2.SummaryStatusInitializing || health.Instance == ec2.SummaryStatusInitializing:
+			summary.Initializing++
+		case health.System == ec2.SummaryStatusOk && health.Instance == ec2.SummaryStatusOk:
+			summary.OK++
+		default:
+			summary.Unknown++
+		}
+		if len(health.Events) > 0 {
+			summary.Scheduled++
+		}
+		if health.Impaired || len(health.Events) > 0 {
+			summary.Instances = append(summary.Instances, health)
+		}
+	}
+	return summary
+}
@@ -0,0 +1,49 @@
+package aws
+
+import (
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+func instanceStatus(id, system, instance string, events ...string) *ec2.InstanceStatus {
+	status := &ec2.InstanceStatus{
+		InstanceId:     awssdk.String(id),
+		InstanceState:  &ec2.InstanceState{Name: awssdk.String("running")},
+		SystemStatus:   &ec2.InstanceStatusSummary{Status: awssdk.String(system)},
+		InstanceStatus: &ec2.InstanceStatusSummary{Status: awssdk.String(instance)},
+	}
+	for _, description := range events {
+		status.Events = append(status.Events, &ec2.InstanceStatusEvent{
+			Code:        awssdk.String("instance-retirement"),
+			Description: awssdk.String(description),
+		})
+	}
+	return status
+}
+
+func TestEc2HealthSummary(t *testing.T) {
+	healths := []*Ec2Health{
+		ec2HealthOf(instanceStatus("i-1", "ok", "ok")),
+		ec2HealthOf(instanceStatus("i-2", "impaired", "ok")),
+		ec2HealthOf(instanceStatus("i-3", "ok", "initializing")),
+		ec2HealthOf(instanceStatus("i-4", "ok", "ok", "The instance is running on degraded hardware")),
+		ec2HealthOf(instanceStatus("i-5", "ok", "ok", "[Completed] The instance is running on degraded hardware")),
+		ec2HealthOf(instanceStatus("i-6", "not-applicable", "not-applicable")),
+	}
+	stopped := instanceStatus("i-7", "not-applicable", "not-applicable")
+	stopped.InstanceState.Name = awssdk.String("stopped")
+	healths = append(healths, ec2HealthOf(stopped))
+	actual := ec2HealthSummary(healths)
+
+	if actual.Count != 7 || actual.OK != 3 || actual.Impaired != 1 || actual.Initializing != 1 || actual.Stopped != 1 || actual.Unknown != 1 {
+		t.Errorf("Expected 7 instances (3 ok, 1 impaired, 1 initializing, 1 stopped, 1 unknown), but got %+v", actual)
+	}
+	if actual.Scheduled != 1 {
+		t.Errorf("Expected %v, but got %v", 1, actual.Scheduled)
+	}
+	if len(actual.Instances) != 2 || actual.Instances[0].InstanceID != "i-2" || actual.Instances[1].InstanceID != "i-4" {
+		t.Errorf("Expected i-2 and i-4 to need attention, but got %v", actual.Instances)
+	}
+}
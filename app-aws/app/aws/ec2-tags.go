@@ -0,0 +1,136 @@
+package aws
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// ErrNoTags means that replacing tags with nothing, which would delete all of them, was requested
+var ErrNoTags = errors.New("tags are required to replace the current ones")
+
+// Ec2TagResult represents a result of tagging an instance
+type Ec2TagResult struct {
+	InstanceID string            `json:"id"`
+	Success    bool              `json:"success"`
+	Tags       map[string]string `json:"tags,omitempty"`
+	Message    string            `json:"message,omitempty"`
+}
+
+// Ec2Tags returns tags of a specified ec2 instance
+func Ec2Tags(id string) (tags map[string]string, e error) {
+	tags = map[string]string{}
+	err := ec2Client().DescribeTagsPages(&ec2.DescribeTagsInput{
+		Filters: []*ec2.Filter{&ec2.Filter{
+			Name:   awssdk.String("resource-id"),
+			Values: []*string{awssdk.String(id)},
+		}},
+	}, func(res *ec2.DescribeTagsOutput, last bool) bool {
+		for _, tag := range res.Tags {
+			tags[awssdk.StringValue(tag.Key)] = awssdk.StringValue(tag.Value)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// Ec2SetTags adds or overwrites tags of a specified ec2 instance
+func Ec2SetTags(id string, tags map[string]string) error {
+	defer invalidateEc2()
+
+	if len(tags) == 0 {
+		return nil
+	}
+	_, err := ec2Client().CreateTags(&ec2.CreateTagsInput{
+		Resources: []*string{awssdk.String(id)},
+		Tags:      ec2TagList(tags),
+	})
+	return err
+}
+
+// Ec2DeleteTags deletes tags of a specified ec2 instance
+func Ec2DeleteTags(id string, keys []string) error {
+	defer invalidateEc2()
+
+	if len(keys) == 0 {
+		return nil
+	}
+	tags := []*ec2.Tag{}
+	for _, key := range keys {
+		tags = append(tags, &ec2.Tag{Key: awssdk.String(key)})
+	}
+	_, err := ec2Client().DeleteTags(&ec2.DeleteTagsInput{
+		Resources: []*string{awssdk.String(id)},
+		Tags:      tags,
+	})
+	return err
+}
+
+// Ec2ReplaceTags makes tags of a specified ec2 instance exactly the same as the argument.
+// Tags reserved by AWS (aws:*) are left as they are. Replacing tags with
+// no tags is refused, use Ec2DeleteTags to remove them.
+func Ec2ReplaceTags(id string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return ErrNoTags
+	}
+	current, err := Ec2Tags(id)
+	if err != nil {
+		return err
+	}
+	obsolete := []string{}
+	for key := range current {
+		if _, found := tags[key]; !found && !strings.HasPrefix(key, "aws:") {
+			obsolete = append(obsolete, key)
+		}
+	}
+	// the new tags are set first not to leave the instance without any
+	if err = Ec2SetTags(id, tags); err != nil {
+		return err
+	}
+	return Ec2DeleteTags(id, obsolete)
+}
+
+// Ec2TagInstances tags ec2 instances one by one and reports results per instance.
+// With replace, tags which are not in the argument are removed.
+func Ec2TagInstances(ids []string, tags map[string]string, replace bool) []*Ec2TagResult {
+	results := []*Ec2TagResult{}
+	for _, id := range ids {
+		var err error
+		if replace {
+			err = Ec2ReplaceTags(id, tags)
+		} else {
+			err = Ec2SetTags(id, tags)
+		}
+		result := &Ec2TagResult{InstanceID: id}
+		if err != nil {
+			result.Message = err.Error()
+			results = append(results, result)
+			continue
+		}
+		if result.Tags, err = Ec2Tags(id); err != nil {
+			result.Message = "tagged, but could not read the tags back: " + err.Error()
+		}
+		result.Success = (err == nil)
+		results = append(results, result)
+	}
+	return results
+}
+
+func ec2TagList(tags map[string]string) []*ec2.Tag {
+	keys := []string{}
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	list := []*ec2.Tag{}
+	for _, key := range keys {
+		list = append(list, &ec2.Tag{Key: awssdk.String(key), Value: awssdk.String(tags[key])})
+	}
+	return list
+}
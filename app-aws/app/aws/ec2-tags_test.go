@@ -0,0 +1,100 @@
+package aws
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestEc2TagInstances(t *testing.T) {
+	tags := map[string]string{}
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		if form.Get("ResourceId.1") == "i-unknown" || form.Get("Filter.1.Value.1") == "i-unknown" {
+			return http.StatusBadRequest, ec2Error("InvalidInstanceID.NotFound")
+		}
+		switch action {
+		case "CreateTags":
+			tags[form.Get("Tag.1.Key")] = form.Get("Tag.1.Value")
+			return http.StatusOK, `<CreateTagsResponse><return>true</return></CreateTagsResponse>`
+		case "DescribeTags":
+			body := `<DescribeTagsResponse><tagSet>`
+			for key, value := range tags {
+				body += `<item><resourceId>` + form.Get("Filter.1.Value.1") + `</resourceId>` +
+					`<key>` + key + `</key><value>` + value + `</value></item>`
+			}
+			return http.StatusOK, body + `</tagSet></DescribeTagsResponse>`
+		}
+		return http.StatusBadRequest, ec2Error("InvalidAction")
+	})()
+
+	actual := Ec2TagInstances([]string{"i-1", "i-unknown"}, map[string]string{"Owner": "ops"}, false)
+	if len(actual) != 2 {
+		t.Errorf("Expected %v results, but got %v", 2, len(actual))
+		return
+	}
+	if !actual[0].Success || actual[0].Tags["Owner"] != "ops" {
+		t.Errorf("Expected i-1 to be tagged, but got %v", actual[0])
+	}
+	if actual[1].Success || actual[1].Message == "" {
+		t.Errorf("Expected i-unknown to fail, but got %v", actual[1])
+	}
+}
+
+func TestEc2ReplaceTagsWithNothing(t *testing.T) {
+	called := false
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		called = true
+		return http.StatusBadRequest, ec2Error("InvalidAction")
+	})()
+
+	if err := Ec2ReplaceTags("i-1", map[string]string{}); err != ErrNoTags {
+		t.Errorf("Expected %v, but got %v", ErrNoTags, err)
+	}
+	actual := Ec2TagInstances([]string{"i-1", "i-2"}, nil, true)
+	for _, result := range actual {
+		if result.Success || result.Message != ErrNoTags.Error() {
+			t.Errorf("Expected %v, but got %v", ErrNoTags, result)
+		}
+	}
+	if called {
+		t.Errorf("Expected no calls to EC2, but it was called")
+	}
+}
+
+func TestEc2TagInstancesReadBackFailure(t *testing.T) {
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		if action == "CreateTags" {
+			return http.StatusOK, `<CreateTagsResponse><return>true</return></CreateTagsResponse>`
+		}
+		return http.StatusServiceUnavailable, ec2Error("Unavailable")
+	})()
+
+	actual := Ec2TagInstances([]string{"i-1"}, map[string]string{"Owner": "ops"}, false)
+	if actual[0].Success || actual[0].Message == "" {
+		t.Errorf("Expected the failure in reading tags back to be reported, but got %v", actual[0])
+	}
+}
+
+func TestEc2ReplaceTagsKeepsTagsOnFailure(t *testing.T) {
+	deleted := false
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		switch action {
+		case "DescribeTags":
+			return http.StatusOK, `<DescribeTagsResponse><tagSet><item><resourceId>i-1</resourceId>` +
+				`<key>Team</key><value>web</value></item></tagSet></DescribeTagsResponse>`
+		case "CreateTags":
+			return http.StatusBadRequest, ec2Error("TagLimitExceeded")
+		case "DeleteTags":
+			deleted = true
+			return http.StatusOK, `<DeleteTagsResponse><return>true</return></DeleteTagsResponse>`
+		}
+		return http.StatusBadRequest, ec2Error("InvalidAction")
+	})()
+
+	if err := Ec2ReplaceTags("i-1", map[string]string{"Owner": "ops"}); err == nil {
+		t.Errorf("Expected the failure of CreateTags, but got nothing")
+	}
+	if deleted {
+		t.Errorf("Expected the current tags not to be deleted when the new ones could not be set")
+	}
+}
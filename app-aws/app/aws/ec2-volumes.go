@@ -0,0 +1,39 @@
+package aws
+
+import (
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// Ec2Volumes returns EBS volumes with their attachments.
+// They can be narrowed down to the ones attached to an instance.
+func Ec2Volumes(ids []string, instanceID string) (volumes []*ec2.Volume, e error) {
+	req := &ec2.DescribeVolumesInput{}
+	if len(ids) > 0 {
+		req.VolumeIds = awssdk.StringSlice(ids)
+	}
+	if instanceID != "" {
+		req.Filters = []*ec2.Filter{&ec2.Filter{
+			Name:   awssdk.String("attachment.instance-id"),
+			Values: []*string{awssdk.String(instanceID)},
+		}}
+	}
+	volumes = []*ec2.Volume{}
+	err := ec2Client().DescribeVolumesPages(req, func(res *ec2.DescribeVolumesOutput, last bool) bool {
+		volumes = append(volumes, res.Volumes...)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return volumes, nil
+}
+
+// Ec2Volume returns a specified EBS volume
+func Ec2Volume(id string) (volume *ec2.Volume, e error) {
+	volumes, err := Ec2Volumes([]string{id}, "")
+	if err != nil || len(volumes) == 0 {
+		return nil, err
+	}
+	return volumes[0], nil
+}
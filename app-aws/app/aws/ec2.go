@@ -4,18 +4,42 @@ package aws
  * @see https://github.com/aws/aws-sdk-go/blob/master/service/ec2/api.go
  */
 import (
+	"sort"
+	"sync"
+
 	awssdk "github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	appcfg "github.com/pottava/golang-microservices/app-aws/app/config"
 	"github.com/pottava/golang-microservices/app-aws/app/logs"
 )
 
-// Ec2Instance returns a specified ec2 instance
+var ec2Cfg *awssdk.Config
+
+func init() {
+	ec2Cfg = config()
+	if endpoint := appcfg.NewConfig().AwsEc2Endpoint; endpoint != "" {
+		ec2Cfg.Endpoint = awssdk.String(endpoint)
+	}
+}
+
+func ec2Client() *ec2.EC2 {
+	return ec2.New(newSession(), ec2Cfg)
+}
+
+func ec2ClientFor(target Target) *ec2.EC2 {
+	return ec2.New(newSession(), configFor(ec2Cfg, target))
+}
+
+// Ec2Instance returns a specified ec2 instance, or nil when it does not exist
 func Ec2Instance(id string) (instance *ec2.Instance, e error) {
 	req := &ec2.DescribeInstancesInput{
 		InstanceIds: []*string{awssdk.String(id)},
 	}
-	res, err := ec2.New(session.New(), awssdk.NewConfig()).DescribeInstances(req)
+	res, err := ec2Client().DescribeInstances(req)
+	if ec2InstanceNotFound(err) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -29,18 +53,270 @@ func Ec2Instance(id string) (instance *ec2.Instance, e error) {
 	return instance, nil
 }
 
-// Ec2Instances responses ec2 instances
-func Ec2Instances() (instances []ec2.Instance, e error) {
-	res, err := ec2.New(session.New(), awssdk.NewConfig()).DescribeInstances(nil)
+// ec2InstanceNotFound tells if the error says that the instance does not exist,
+// including IDs which are not even in the form of one
+func ec2InstanceNotFound(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && (aerr.Code() == "InvalidInstanceID.NotFound" || aerr.Code() == "InvalidInstanceID.Malformed")
+}
+
+// Ec2InstanceQuery represents conditions to list ec2 instances
+type Ec2InstanceQuery struct {
+	States    []string
+	Types     []string
+	VpcIDs    []string
+	Tags      map[string][]string
+	TagKeys   []string
+	PageSize  int64
+	NextToken string
+	AllPages  bool
+}
+
+// Ec2InstancePage represents a page of ec2 instances
+type Ec2InstancePage struct {
+	Instances []*ec2.Instance `json:"instances"`
+	Count     int             `json:"count"`
+	NextToken string          `json:"nextToken,omitempty"`
+}
+
+// Ec2Instances responses ec2 instances which match the query.
+// It walks through all pages when the query asks for them,
+// otherwise returns only one page with a token to continue.
+func Ec2Instances(query Ec2InstanceQuery) (page *Ec2InstancePage, e error) {
+	return ec2Instances(ec2Client(), query)
+}
+
+func ec2Instances(client *ec2.EC2, query Ec2InstanceQuery) (page *Ec2InstancePage, e error) {
+	req := &ec2.DescribeInstancesInput{Filters: query.filters()}
+	if query.PageSize > 0 {
+		req.MaxResults = awssdk.Int64(ec2PageSize(query.PageSize))
+	}
+	if query.NextToken != "" {
+		req.NextToken = awssdk.String(query.NextToken)
+	}
+	page = &Ec2InstancePage{Instances: []*ec2.Instance{}}
+	found := map[string]bool{}
+
+	err := client.DescribeInstancesPages(req, func(res *ec2.DescribeInstancesOutput, last bool) bool {
+		for _, reservation := range res.Reservations {
+			for _, inst := range reservation.Instances {
+				if inst.InstanceId == nil || found[*inst.InstanceId] {
+					continue
+				}
+				found[*inst.InstanceId] = true
+				page.Instances = append(page.Instances, inst)
+			}
+		}
+		page.NextToken = awssdk.StringValue(res.NextToken)
+		return query.AllPages
+	})
 	if err != nil {
 		logs.Error.Print("Could not describe EC2 Instances.")
 		return nil, err
 	}
-	instances = make([]ec2.Instance, len(res.Reservations))
-	for idx := range res.Reservations {
-		for _, inst := range res.Reservations[idx].Instances {
-			instances[idx] = *inst
+	if query.AllPages {
+		page.NextToken = ""
+	}
+	page.Count = len(page.Instances)
+	return page, nil
+}
+
+// Ec2RegionalInstance represents an ec2 instance with its region and account
+type Ec2RegionalInstance struct {
+	Region  string `json:"Region"`
+	Account string `json:"Account,omitempty"`
+	*ec2.Instance
+}
+
+// Ec2RegionalError represents a failure in listing instances of a region
+type Ec2RegionalError struct {
+	Region  string `json:"region"`
+	Account string `json:"account,omitempty"`
+	Message string `json:"message"`
+}
+
+// Ec2Inventory represents ec2 instances aggregated over regions and accounts
+type Ec2Inventory struct {
+	Instances []*Ec2RegionalInstance `json:"instances"`
+	Count     int                    `json:"count"`
+	Errors    []*Ec2RegionalError    `json:"errors"`
+}
+
+// Ec2InstancesInRegions lists ec2 instances of all the targets concurrently,
+// with as many workers as configured at most.
+// A failure in some targets is reported along with the others' instances.
+func Ec2InstancesInRegions(query Ec2InstanceQuery, targets []Target) *Ec2Inventory {
+	query.AllPages = true
+	query.PageSize = 0
+	query.NextToken = ""
+
+	pages := make([]*Ec2InstancePage, len(targets))
+	errs := make([]error, len(targets))
+
+	workers := appcfg.NewConfig().AwsRegionWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	semaphore := make(chan struct{}, workers)
+
+	var wg sync.WaitGroup
+	for idx := range targets {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			pages[idx], errs[idx] = ec2Instances(ec2ClientFor(targets[idx]), query)
+		}(idx)
+	}
+	wg.Wait()
+
+	inventory := &Ec2Inventory{
+		Instances: []*Ec2RegionalInstance{},
+		Errors:    []*Ec2RegionalError{},
+	}
+	for idx, target := range targets {
+		if errs[idx] != nil {
+			logs.Warn.Printf("Could not describe EC2 Instances in %s %s. Error: %v", target.Region, target.Account, errs[idx])
+			inventory.Errors = append(inventory.Errors, &Ec2RegionalError{
+				Region:  target.Region,
+				Account: target.Account,
+				Message: errs[idx].Error(),
+			})
+			continue
+		}
+		for _, instance := range pages[idx].Instances {
+			inventory.Instances = append(inventory.Instances, &Ec2RegionalInstance{
+				Region:   target.Region,
+				Account:  target.Account,
+				Instance: instance,
+			})
+		}
+	}
+	inventory.Count = len(inventory.Instances)
+	return inventory
+}
+
+func (query Ec2InstanceQuery) filters() []*ec2.Filter {
+	filters := []*ec2.Filter{}
+	add := func(name string, values []string) {
+		if len(values) > 0 {
+			filters = append(filters, &ec2.Filter{
+				Name:   awssdk.String(name),
+				Values: awssdk.StringSlice(values),
+			})
+		}
+	}
+	add("instance-state-name", query.States)
+	add("instance-type", query.Types)
+	add("vpc-id", query.VpcIDs)
+	add("tag-key", query.TagKeys)
+	keys := []string{}
+	for key := range query.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		add("tag:"+key, query.Tags[key])
+	}
+	if len(filters) == 0 {
+		return nil
+	}
+	return filters
+}
+
+// ec2PageSize keeps the page size in the range DescribeInstances accepts
+func ec2PageSize(size int64) int64 {
+	if size < 5 {
+		return 5
+	}
+	if size > 1000 {
+		return 1000
+	}
+	return size
+}
+
+// Ec2StartInstance starts a specified ec2 instance
+func Ec2StartInstance(id string, dryRun bool) (change *ec2.InstanceStateChange, e error) {
+	defer invalidateEc2()
+
+	res, err := ec2Client().StartInstances(&ec2.StartInstancesInput{
+		InstanceIds: []*string{awssdk.String(id)},
+		DryRun:      awssdk.Bool(dryRun),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ec2StateChange(id, res.StartingInstances), nil
+}
+
+// Ec2StopInstance stops a specified ec2 instance
+func Ec2StopInstance(id string, dryRun bool) (change *ec2.InstanceStateChange, e error) {
+	defer invalidateEc2()
+
+	res, err := ec2Client().StopInstances(&ec2.StopInstancesInput{
+		InstanceIds: []*string{awssdk.String(id)},
+		DryRun:      awssdk.Bool(dryRun),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ec2StateChange(id, res.StoppingInstances), nil
+}
+
+// Ec2RebootInstance reboots a specified ec2 instance.
+// A reboot does not change the instance state, so both of the previous and
+// the current state are the one observed after the request was accepted.
+func Ec2RebootInstance(id string, dryRun bool) (change *ec2.InstanceStateChange, e error) {
+	defer invalidateEc2()
+
+	_, err := ec2Client().RebootInstances(&ec2.RebootInstancesInput{
+		InstanceIds: []*string{awssdk.String(id)},
+		DryRun:      awssdk.Bool(dryRun),
+	})
+	if err != nil {
+		return nil, err
+	}
+	instance, err := Ec2Instance(id)
+	if err != nil {
+		return nil, err
+	}
+	change = &ec2.InstanceStateChange{InstanceId: awssdk.String(id)}
+	if instance != nil {
+		change.PreviousState = instance.State
+		change.CurrentState = instance.State
+	}
+	return change, nil
+}
+
+// Ec2TerminateInstance terminates a specified ec2 instance
+func Ec2TerminateInstance(id string, dryRun bool) (change *ec2.InstanceStateChange, e error) {
+	defer invalidateEc2()
+
+	res, err := ec2Client().TerminateInstances(&ec2.TerminateInstancesInput{
+		InstanceIds: []*string{awssdk.String(id)},
+		DryRun:      awssdk.Bool(dryRun),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ec2StateChange(id, res.TerminatingInstances), nil
+}
+
+// Ec2DryRunSucceeded checks if the error means that a dry-run request
+// would have succeeded
+func Ec2DryRunSucceeded(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code() == "DryRunOperation"
+	}
+	return false
+}
+
+func ec2StateChange(id string, changes []*ec2.InstanceStateChange) *ec2.InstanceStateChange {
+	for _, change := range changes {
+		if change.InstanceId != nil && *change.InstanceId == id {
+			return change
 		}
 	}
-	return instances, nil
+	return &ec2.InstanceStateChange{InstanceId: awssdk.String(id)}
 }
@@ -0,0 +1,202 @@
+package aws
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func ec2Error(code string) string {
+	return `<Response><Errors><Error><Code>` + code + `</Code><Message>` + code +
+		`</Message></Error></Errors><RequestID>req</RequestID></Response>`
+}
+
+func ec2StateChangeXML(action, set, id, previous, current string) string {
+	return `<` + action + `Response><requestId>req</requestId><` + set + `><item>` +
+		`<instanceId>` + id + `</instanceId>` +
+		`<currentState><code>0</code><name>` + current + `</name></currentState>` +
+		`<previousState><code>0</code><name>` + previous + `</name></previousState>` +
+		`</item></` + set + `></` + action + `Response>`
+}
+
+func TestEc2InstanceNotFound(t *testing.T) {
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		if form.Get("InstanceId.1") == "i-unknown" {
+			return http.StatusBadRequest, ec2Error("InvalidInstanceID.NotFound")
+		}
+		if form.Get("InstanceId.1") == "unknown" {
+			return http.StatusBadRequest, ec2Error("InvalidInstanceID.Malformed")
+		}
+		return http.StatusOK, `<DescribeInstancesResponse><reservationSet/></DescribeInstancesResponse>`
+	})()
+
+	for _, id := range []string{"i-unknown", "unknown", "i-1"} {
+		actual, err := Ec2Instance(id)
+		if actual != nil || err != nil {
+			t.Errorf("Expected nothing for %v, but got %v, %v", id, actual, err)
+		}
+	}
+}
+
+func TestEc2StartInstance(t *testing.T) {
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		if action != "StartInstances" || form.Get("InstanceId.1") != "i-1" {
+			return http.StatusBadRequest, ec2Error("InvalidParameterValue")
+		}
+		return http.StatusOK, ec2StateChangeXML("StartInstances", "instancesSet", "i-1", "stopped", "pending")
+	})()
+
+	actual, err := Ec2StartInstance("i-1", false)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if *actual.PreviousState.Name != "stopped" || *actual.CurrentState.Name != "pending" {
+		t.Errorf("Expected stopped -> pending, but got %v -> %v", *actual.PreviousState.Name, *actual.CurrentState.Name)
+	}
+}
+
+func TestEc2StopInstance(t *testing.T) {
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		return http.StatusOK, ec2StateChangeXML("StopInstances", "instancesSet", "i-1", "running", "stopping")
+	})()
+
+	actual, err := Ec2StopInstance("i-1", false)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if *actual.PreviousState.Name != "running" || *actual.CurrentState.Name != "stopping" {
+		t.Errorf("Expected running -> stopping, but got %v -> %v", *actual.PreviousState.Name, *actual.CurrentState.Name)
+	}
+}
+
+func TestEc2TerminateInstance(t *testing.T) {
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		return http.StatusOK, ec2StateChangeXML("TerminateInstances", "instancesSet", "i-1", "running", "shutting-down")
+	})()
+
+	actual, err := Ec2TerminateInstance("i-1", false)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if *actual.CurrentState.Name != "shutting-down" {
+		t.Errorf("Expected %v, but got %v", "shutting-down", *actual.CurrentState.Name)
+	}
+}
+
+func TestEc2RebootInstance(t *testing.T) {
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		switch action {
+		case "RebootInstances":
+			return http.StatusOK, `<RebootInstancesResponse><return>true</return></RebootInstancesResponse>`
+		case "DescribeInstances":
+			return http.StatusOK, `<DescribeInstancesResponse><reservationSet><item><instancesSet><item>` +
+				`<instanceId>i-1</instanceId><instanceState><code>16</code><name>running</name></instanceState>` +
+				`</item></instancesSet></item></reservationSet></DescribeInstancesResponse>`
+		}
+		return http.StatusBadRequest, ec2Error("InvalidAction")
+	})()
+
+	actual, err := Ec2RebootInstance("i-1", false)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if *actual.PreviousState.Name != "running" || *actual.CurrentState.Name != "running" {
+		t.Errorf("Expected running -> running, but got %v", actual)
+	}
+}
+
+func TestEc2DryRun(t *testing.T) {
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		if form.Get("DryRun") != "true" {
+			return http.StatusBadRequest, ec2Error("InvalidParameterValue")
+		}
+		return http.StatusPreconditionFailed, ec2Error("DryRunOperation")
+	})()
+
+	_, err := Ec2StopInstance("i-1", true)
+	if !Ec2DryRunSucceeded(err) {
+		t.Errorf("Expected a dry-run success, but got %v", err)
+	}
+	_, err = Ec2StopInstance("i-1", false)
+	if err == nil || Ec2DryRunSucceeded(err) {
+		t.Errorf("Expected an error, but got %v", err)
+	}
+}
+
+func TestEc2Instances(t *testing.T) {
+	pages := map[string]string{
+		"": `<DescribeInstancesResponse><reservationSet>` +
+			`<item><instancesSet><item><instanceId>i-1</instanceId></item><item><instanceId>i-2</instanceId></item></instancesSet></item>` +
+			`</reservationSet><nextToken>page2</nextToken></DescribeInstancesResponse>`,
+		"page2": `<DescribeInstancesResponse><reservationSet>` +
+			`<item><instancesSet><item><instanceId>i-2</instanceId></item><item><instanceId>i-3</instanceId></item></instancesSet></item>` +
+			`</reservationSet></DescribeInstancesResponse>`,
+	}
+	var filters []string
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		filters = []string{form.Get("Filter.1.Name"), form.Get("Filter.1.Value.1"), form.Get("Filter.2.Name")}
+		return http.StatusOK, pages[form.Get("NextToken")]
+	})()
+
+	actual, err := Ec2Instances(Ec2InstanceQuery{
+		States:   []string{"running"},
+		Tags:     map[string][]string{"Env": []string{"dev"}},
+		AllPages: true,
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if actual.Count != 3 || actual.NextToken != "" {
+		t.Errorf("Expected %v instances without a token, but got %v", 3, actual)
+		return
+	}
+	expected := []string{"instance-state-name", "running", "tag:Env"}
+	if !reflect.DeepEqual(filters, expected) {
+		t.Errorf("Expected %v, but got %v", expected, filters)
+		return
+	}
+
+	actual, err = Ec2Instances(Ec2InstanceQuery{PageSize: 2})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if actual.Count != 2 || actual.NextToken != "page2" {
+		t.Errorf("Expected %v instances with a token, but got %v", 2, actual)
+	}
+}
+
+func TestEc2InstancesInRegions(t *testing.T) {
+	defer fakeQueryEndpoint(&ec2Cfg, func(action string, form url.Values) (int, string) {
+		if form.Get("Region") == "eu-west-1" {
+			return http.StatusForbidden, ec2Error("UnauthorizedOperation")
+		}
+		return http.StatusOK, `<DescribeInstancesResponse><reservationSet><item><instancesSet>` +
+			`<item><instanceId>i-` + form.Get("Region") + `</instanceId></item>` +
+			`</instancesSet></item></reservationSet></DescribeInstancesResponse>`
+	})()
+
+	actual := Ec2InstancesInRegions(Ec2InstanceQuery{}, []Target{
+		Target{Region: "us-east-1"},
+		Target{Region: "eu-west-1"},
+		Target{Region: "ap-northeast-1"},
+	})
+	if actual.Count != 2 || len(actual.Errors) != 1 {
+		t.Errorf("Expected 2 instances and 1 error, but got %v instances and %v errors", actual.Count, len(actual.Errors))
+		return
+	}
+	for _, instance := range actual.Instances {
+		if *instance.InstanceId != "i-"+instance.Region {
+			t.Errorf("Expected %v, but got %v", "i-"+instance.Region, *instance.InstanceId)
+		}
+	}
+	if actual.Errors[0].Region != "eu-west-1" {
+		t.Errorf("Expected %v, but got %v", "eu-west-1", actual.Errors[0].Region)
+	}
+}
@@ -0,0 +1,170 @@
+package aws
+
+/**
+ * @see https://github.com/aws/aws-sdk-go/blob/master/service/ecs/api.go
+ */
+import (
+	"sort"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+)
+
+var ecsCfg *awssdk.Config
+
+func init() {
+	ecsCfg = config()
+}
+
+func ecsClient() *ecs.ECS {
+	return ecs.New(newSession(), ecsCfg)
+}
+
+// How many resources ECS describes at once
+const (
+	ecsDescribeClusters = 100
+	ecsDescribeServices = 10
+	ecsDescribeTasks    = 100
+)
+
+// EcsClusters returns ECS clusters
+func EcsClusters() (clusters []*ecs.Cluster, e error) {
+	client := ecsClient()
+	arns := []*string{}
+	err := client.ListClustersPages(&ecs.ListClustersInput{}, func(res *ecs.ListClustersOutput, last bool) bool {
+		arns = append(arns, res.ClusterArns...)
+		return true
+	})
+	if err != nil {
+		logs.Error.Print("Could not list ECS clusters.")
+		return nil, err
+	}
+	clusters = []*ecs.Cluster{}
+	for _, chunk := range chunks(arns, ecsDescribeClusters) {
+		res, err := client.DescribeClusters(&ecs.DescribeClustersInput{Clusters: chunk})
+		if err != nil {
+			logs.Error.Print("Could not describe ECS clusters.")
+			return nil, err
+		}
+		clusters = append(clusters, res.Clusters...)
+	}
+	return clusters, nil
+}
+
+// EcsServices returns services of a cluster with their desired and running counts
+func EcsServices(cluster string) (services []*ecs.Service, e error) {
+	client := ecsClient()
+	arns := []*string{}
+	err := client.ListServicesPages(&ecs.ListServicesInput{Cluster: awssdk.String(cluster)},
+		func(res *ecs.ListServicesOutput, last bool) bool {
+			arns = append(arns, res.ServiceArns...)
+			return true
+		})
+	if err != nil {
+		logs.Error.Printf("Could not list ECS services of %s.", cluster)
+		return nil, err
+	}
+	return ecsServices(cluster, arns)
+}
+
+// EcsService returns a specified service, or nil when it does not exist
+func EcsService(cluster, service string) (*ecs.Service, error) {
+	services, err := ecsServices(cluster, []*string{awssdk.String(service)})
+	if err != nil || len(services) == 0 {
+		return nil, err
+	}
+	return services[0], nil
+}
+
+func ecsServices(cluster string, arns []*string) (services []*ecs.Service, e error) {
+	services = []*ecs.Service{}
+	for _, chunk := range chunks(arns, ecsDescribeServices) {
+		res, err := ecsClient().DescribeServices(&ecs.DescribeServicesInput{
+			Cluster:  awssdk.String(cluster),
+			Services: chunk,
+		})
+		if err != nil {
+			logs.Error.Printf("Could not describe ECS services of %s.", cluster)
+			return nil, err
+		}
+		// missing services are reported as failures, and deleted ones stay INACTIVE for a while
+		for _, service := range res.Services {
+			if awssdk.StringValue(service.Status) != "INACTIVE" {
+				services = append(services, service)
+			}
+		}
+	}
+	return services, nil
+}
+
+// EcsTasks returns running and recently stopped tasks of a cluster, or of
+// a service when it is specified, with their containers and why they stopped.
+// Running ones come first, and newer ones first among them.
+func EcsTasks(cluster, service string) (tasks []*ecs.Task, e error) {
+	client := ecsClient()
+	arns := []*string{}
+	for _, status := range []string{ecs.DesiredStatusRunning, ecs.DesiredStatusStopped} {
+		req := &ecs.ListTasksInput{Cluster: awssdk.String(cluster), DesiredStatus: awssdk.String(status)}
+		if service != "" {
+			req.ServiceName = awssdk.String(service)
+		}
+		err := client.ListTasksPages(req, func(res *ecs.ListTasksOutput, last bool) bool {
+			arns = append(arns, res.TaskArns...)
+			return true
+		})
+		if err != nil {
+			logs.Error.Printf("Could not list ECS tasks of %s.", cluster)
+			return nil, err
+		}
+	}
+	tasks = []*ecs.Task{}
+	for _, chunk := range chunks(arns, ecsDescribeTasks) {
+		res, err := client.DescribeTasks(&ecs.DescribeTasksInput{Cluster: awssdk.String(cluster), Tasks: chunk})
+		if err != nil {
+			logs.Error.Printf("Could not describe ECS tasks of %s.", cluster)
+			return nil, err
+		}
+		tasks = append(tasks, res.Tasks...)
+	}
+	sortEcsTasks(tasks)
+	return tasks, nil
+}
+
+// EcsForceDeployment replaces the tasks of a service with new ones
+// of the same task definition, e.g. to pull the latest image of a tag
+func EcsForceDeployment(cluster, service string) (*ecs.Service, error) {
+	res, err := ecsClient().UpdateService(&ecs.UpdateServiceInput{
+		Cluster:            awssdk.String(cluster),
+		Service:            awssdk.String(service),
+		ForceNewDeployment: awssdk.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Service, nil
+}
+
+func sortEcsTasks(tasks []*ecs.Task) {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		ri := awssdk.StringValue(tasks[i].DesiredStatus) == ecs.DesiredStatusRunning
+		rj := awssdk.StringValue(tasks[j].DesiredStatus) == ecs.DesiredStatusRunning
+		if ri != rj {
+			return ri
+		}
+		return awssdk.TimeValue(tasks[i].CreatedAt).After(awssdk.TimeValue(tasks[j].CreatedAt))
+	})
+}
+
+// chunks splits values into slices of the size at most
+func chunks(values []*string, size int) [][]*string {
+	result := [][]*string{}
+	for start := 0; start < len(values); start += size {
+		end := start + size
+		if end > len(values) {
+			end = len(values)
+		}
+		result = append(result, values[start:end])
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+package aws
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// fakeEcs points the ecs client to a local endpoint which answers
+// with the given function, and returns a function to restore the client
+func fakeEcs(handler func(action string, req map[string]interface{}) string) func() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&req)
+		action := r.Header.Get("X-Amz-Target")
+		action = action[strings.LastIndex(action, ".")+1:]
+		w.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		w.Write([]byte(handler(action, req)))
+	}))
+	original := ecsCfg
+	ecsCfg = &awssdk.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    awssdk.String(server.URL),
+		Region:      awssdk.String("us-east-1"),
+		MaxRetries:  awssdk.Int(0),
+	}
+	return func() {
+		ecsCfg = original
+		server.Close()
+	}
+}
+
+func TestEcsTasks(t *testing.T) {
+	var described []interface{}
+	defer fakeEcs(func(action string, req map[string]interface{}) string {
+		switch action {
+		case "ListTasks":
+			if req["serviceName"] != "web" {
+				return `{"taskArns": []}`
+			}
+			if req["desiredStatus"] == "STOPPED" {
+				return `{"taskArns": ["arn:task/stopped"]}`
+			}
+			return `{"taskArns": ["arn:task/old", "arn:task/new"]}`
+		case "DescribeTasks":
+			described = req["tasks"].([]interface{})
+			return `{"tasks": [` +
+				`{"taskArn": "arn:task/stopped", "desiredStatus": "STOPPED", "createdAt": 1451692800, ` +
+				`"stoppedReason": "Essential container in task exited", ` +
+				`"containers": [{"name": "app", "lastStatus": "STOPPED", "exitCode": 1}]},` +
+				`{"taskArn": "arn:task/old", "desiredStatus": "RUNNING", "createdAt": 1451606400},` +
+				`{"taskArn": "arn:task/new", "desiredStatus": "RUNNING", "createdAt": 1451779200}]}`
+		}
+		return `{}`
+	})()
+
+	actual, err := EcsTasks("default", "web")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if len(described) != 3 {
+		t.Errorf("Expected %v tasks to be described, but got %v", 3, described)
+	}
+	expected := []string{"arn:task/new", "arn:task/old", "arn:task/stopped"}
+	for idx, task := range actual {
+		if *task.TaskArn != expected[idx] {
+			t.Errorf("Expected %v, but got %v", expected[idx], *task.TaskArn)
+		}
+	}
+	if stopped := actual[2]; *stopped.Containers[0].ExitCode != 1 || *stopped.StoppedReason == "" {
+		t.Errorf("Expected why the task stopped, but got %v", stopped)
+	}
+}
+
+func TestEcsServices(t *testing.T) {
+	var batches [][]interface{}
+	defer fakeEcs(func(action string, req map[string]interface{}) string {
+		switch action {
+		case "ListServices":
+			arns := []string{}
+			for i := 0; i < 12; i++ {
+				arns = append(arns, `"arn:service/`+string(rune('a'+i))+`"`)
+			}
+			return `{"serviceArns": [` + strings.Join(arns, ",") + `]}`
+		case "DescribeServices":
+			batch := req["services"].([]interface{})
+			batches = append(batches, batch)
+			services := []string{}
+			for _, arn := range batch {
+				status := "ACTIVE"
+				if arn == "arn:service/l" {
+					status = "INACTIVE"
+				}
+				services = append(services, `{"serviceArn": "`+arn.(string)+`", "status": "`+status+`", "desiredCount": 2, "runningCount": 1}`)
+			}
+			return `{"services": [` + strings.Join(services, ",") + `]}`
+		}
+		return `{}`
+	})()
+
+	actual, err := EcsServices("default")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if len(batches) != 2 || len(batches[0]) != 10 || len(batches[1]) != 2 {
+		t.Errorf("Expected services to be described by 10, but got %v", batches)
+	}
+	if len(actual) != 11 || *actual[0].DesiredCount != 2 || *actual[0].RunningCount != 1 {
+		t.Errorf("Expected 11 active services, but got %v", len(actual))
+	}
+}
@@ -0,0 +1,39 @@
+package aws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// fakeQueryEndpoint points the client config of a query protocol service,
+// such as EC2 or RDS, to a local endpoint which answers with the given function,
+// and returns a function to restore the config.
+// The region signed the request for is passed as a "Region" form value.
+func fakeQueryEndpoint(cfg **awssdk.Config, handler func(action string, form url.Values) (int, string)) func() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if scope := strings.Split(r.Header.Get("Authorization"), "/"); len(scope) > 2 {
+			r.Form.Set("Region", scope[2])
+		}
+		code, body := handler(r.Form.Get("Action"), r.Form)
+		w.Header().Set("Content-Type", "text/xml")
+		w.WriteHeader(code)
+		w.Write([]byte(body))
+	}))
+	original := *cfg
+	*cfg = &awssdk.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    awssdk.String(server.URL),
+		Region:      awssdk.String("us-east-1"),
+		MaxRetries:  awssdk.Int(0),
+	}
+	return func() {
+		*cfg = original
+		server.Close()
+	}
+}
@@ -0,0 +1,233 @@
+package aws
+
+/**
+ * @see https://github.com/aws/aws-sdk-go/blob/master/service/iam/api.go
+ */
+import (
+	"sync"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+)
+
+var iamCfg *awssdk.Config
+
+func init() {
+	iamCfg = config()
+}
+
+func iamClient() *iam.IAM {
+	return iam.New(newSession(), iamCfg)
+}
+
+// iamConcurrency limits how many users are inspected at once
+// not to be throttled by IAM
+const iamConcurrency = 5
+
+// Kinds of findings of an IAM audit
+const (
+	IamFindingOldKey = "access-key-too-old"
+	IamFindingNoMFA  = "mfa-not-enabled"
+)
+
+// IamAccessKey represents an access key with when it was used last
+type IamAccessKey struct {
+	AccessKeyID     string     `json:"accessKeyId"`
+	Status          string     `json:"status"`
+	CreateDate      time.Time  `json:"createDate"`
+	AgeDays         int        `json:"ageDays"`
+	LastUsedDate    *time.Time `json:"lastUsedDate,omitempty"`
+	LastUsedService string     `json:"lastUsedService,omitempty"`
+	LastUsedRegion  string     `json:"lastUsedRegion,omitempty"`
+}
+
+// IamUser represents an IAM user with its credentials and attached policies
+type IamUser struct {
+	*iam.User
+	ConsoleAccess bool                  `json:"ConsoleAccess"`
+	MFA           bool                  `json:"MFA"`
+	AccessKeys    []*IamAccessKey       `json:"AccessKeys"`
+	Policies      []*iam.AttachedPolicy `json:"Policies"`
+}
+
+// IamRole represents an IAM role with its attached policies
+type IamRole struct {
+	*iam.Role
+	Policies []*iam.AttachedPolicy `json:"Policies"`
+}
+
+// IamFinding represents something to be fixed about a user
+type IamFinding struct {
+	UserName    string `json:"userName"`
+	Kind        string `json:"kind"`
+	AccessKeyID string `json:"accessKeyId,omitempty"`
+	AgeDays     int    `json:"ageDays,omitempty"`
+}
+
+// IamAuditReport represents findings of an IAM audit
+type IamAuditReport struct {
+	GeneratedAt time.Time     `json:"generatedAt"`
+	MaxKeyAge   int           `json:"maxKeyAgeDays"`
+	Users       int           `json:"users"`
+	Findings    []*IamFinding `json:"findings"`
+}
+
+// IamUsers returns IAM users with their access keys, MFA devices and attached policies
+func IamUsers() (users []*IamUser, e error) {
+	list := []*iam.User{}
+	err := iamClient().ListUsersPages(&iam.ListUsersInput{}, func(res *iam.ListUsersOutput, last bool) bool {
+		list = append(list, res.Users...)
+		return true
+	})
+	if err != nil {
+		logs.Error.Print("Could not list IAM users.")
+		return nil, err
+	}
+	users = make([]*IamUser, len(list))
+	errs := make([]error, len(list))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, iamConcurrency)
+	for idx := range list {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			users[idx], errs[idx] = iamUser(list[idx], time.Now())
+		}(idx)
+	}
+	wg.Wait()
+
+	for idx, err := range errs {
+		if err != nil {
+			logs.Error.Printf("Could not inspect IAM user %s.", awssdk.StringValue(list[idx].UserName))
+			return nil, err
+		}
+	}
+	return users, nil
+}
+
+func iamUser(user *iam.User, now time.Time) (*IamUser, error) {
+	client := iamClient()
+	name := user.UserName
+	result := &IamUser{User: user, AccessKeys: []*IamAccessKey{}, Policies: []*iam.AttachedPolicy{}}
+
+	keys, err := client.ListAccessKeys(&iam.ListAccessKeysInput{UserName: name})
+	if err != nil {
+		return nil, err
+	}
+	for _, metadata := range keys.AccessKeyMetadata {
+		key := &IamAccessKey{
+			AccessKeyID: awssdk.StringValue(metadata.AccessKeyId),
+			Status:      awssdk.StringValue(metadata.Status),
+			CreateDate:  awssdk.TimeValue(metadata.CreateDate),
+		}
+		key.AgeDays = int(now.Sub(key.CreateDate).Hours() / 24)
+
+		used, err := client.GetAccessKeyLastUsed(&iam.GetAccessKeyLastUsedInput{AccessKeyId: metadata.AccessKeyId})
+		if err != nil {
+			return nil, err
+		}
+		if last := used.AccessKeyLastUsed; last != nil && last.LastUsedDate != nil {
+			key.LastUsedDate = last.LastUsedDate
+			key.LastUsedService = awssdk.StringValue(last.ServiceName)
+			key.LastUsedRegion = awssdk.StringValue(last.Region)
+		}
+		result.AccessKeys = append(result.AccessKeys, key)
+	}
+
+	// users without a login profile can not sign in to the console
+	_, err = client.GetLoginProfile(&iam.GetLoginProfileInput{UserName: name})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == iam.ErrCodeNoSuchEntityException {
+		err = nil
+	} else if err == nil {
+		result.ConsoleAccess = true
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	devices, err := client.ListMFADevices(&iam.ListMFADevicesInput{UserName: name})
+	if err != nil {
+		return nil, err
+	}
+	result.MFA = len(devices.MFADevices) > 0
+
+	err = client.ListAttachedUserPoliciesPages(&iam.ListAttachedUserPoliciesInput{UserName: name},
+		func(res *iam.ListAttachedUserPoliciesOutput, last bool) bool {
+			result.Policies = append(result.Policies, res.AttachedPolicies...)
+			return true
+		})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// IamRoles returns IAM roles with their attached policies
+func IamRoles() (roles []*IamRole, e error) {
+	client := iamClient()
+	roles = []*IamRole{}
+	err := client.ListRolesPages(&iam.ListRolesInput{}, func(res *iam.ListRolesOutput, last bool) bool {
+		for _, role := range res.Roles {
+			roles = append(roles, &IamRole{Role: role, Policies: []*iam.AttachedPolicy{}})
+		}
+		return true
+	})
+	if err != nil {
+		logs.Error.Print("Could not list IAM roles.")
+		return nil, err
+	}
+	for _, role := range roles {
+		err = client.ListAttachedRolePoliciesPages(&iam.ListAttachedRolePoliciesInput{RoleName: role.RoleName},
+			func(res *iam.ListAttachedRolePoliciesOutput, last bool) bool {
+				role.Policies = append(role.Policies, res.AttachedPolicies...)
+				return true
+			})
+		if err != nil {
+			logs.Error.Printf("Could not list policies of IAM role %s.", awssdk.StringValue(role.RoleName))
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+// IamAudit flags active access keys older than maxAge days,
+// and users who can sign in to the console without MFA
+func IamAudit(maxAge int) (report *IamAuditReport, e error) {
+	users, err := IamUsers()
+	if err != nil {
+		return nil, err
+	}
+	return iamAudit(users, maxAge, time.Now()), nil
+}
+
+func iamAudit(users []*IamUser, maxAge int, now time.Time) *IamAuditReport {
+	report := &IamAuditReport{
+		GeneratedAt: now,
+		MaxKeyAge:   maxAge,
+		Users:       len(users),
+		Findings:    []*IamFinding{},
+	}
+	for _, user := range users {
+		name := awssdk.StringValue(user.UserName)
+		if user.ConsoleAccess && !user.MFA {
+			report.Findings = append(report.Findings, &IamFinding{UserName: name, Kind: IamFindingNoMFA})
+		}
+		for _, key := range user.AccessKeys {
+			if key.Status == iam.StatusTypeActive && key.CreateDate.AddDate(0, 0, maxAge).Before(now) {
+				report.Findings = append(report.Findings, &IamFinding{
+					UserName:    name,
+					Kind:        IamFindingOldKey,
+					AccessKeyID: key.AccessKeyID,
+					AgeDays:     key.AgeDays,
+				})
+			}
+		}
+	}
+	return report
+}
@@ -0,0 +1,180 @@
+package aws
+
+/**
+ * @see https://github.com/aws/aws-sdk-go/blob/master/service/lambda/api.go
+ */
+import (
+	"encoding/base64"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+)
+
+var lambdaCfg *awssdk.Config
+
+func init() {
+	lambdaCfg = config()
+}
+
+func lambdaClient() *lambda.Lambda {
+	return lambda.New(newSession(), lambdaCfg)
+}
+
+// LambdaRedacted replaces values of environment variables
+const LambdaRedacted = "(redacted)"
+
+// LambdaFunction represents a function in the inventory
+type LambdaFunction struct {
+	Name         string `json:"name"`
+	Arn          string `json:"arn"`
+	Runtime      string `json:"runtime"`
+	Handler      string `json:"handler"`
+	MemorySize   int64  `json:"memorySize"`
+	Timeout      int64  `json:"timeout"`
+	CodeSize     int64  `json:"codeSize"`
+	LastModified string `json:"lastModified"`
+}
+
+// LambdaInvocation is the result of a synchronous invocation. Payload is
+// the returned JSON, or a string when it is not a JSON.
+type LambdaInvocation struct {
+	StatusCode      int64       `json:"statusCode"`
+	FunctionError   string      `json:"functionError,omitempty"`
+	ExecutedVersion string      `json:"executedVersion,omitempty"`
+	Payload         interface{} `json:"payload"`
+	LogTail         string      `json:"logTail"`
+	Duration        float64     `json:"durationMs"`
+	BilledDuration  float64     `json:"billedDurationMs"`
+	InitDuration    float64     `json:"initDurationMs,omitempty"`
+	RoundTrip       float64     `json:"roundTripMs"`
+}
+
+// "REPORT RequestId: ...\tDuration: 12.34 ms\tBilled Duration: 13 ms\t...\tInit Duration: 150.12 ms",
+// where Init Duration is reported only by cold starts
+var lambdaReport = regexp.MustCompile(`\t(Billed |Init )?Duration: ([0-9.]+) ms`)
+
+// LambdaFunctions returns functions
+func LambdaFunctions() (functions []*LambdaFunction, e error) {
+	functions = []*LambdaFunction{}
+	err := lambdaClient().ListFunctionsPages(&lambda.ListFunctionsInput{}, func(res *lambda.ListFunctionsOutput, last bool) bool {
+		for _, function := range res.Functions {
+			functions = append(functions, &LambdaFunction{
+				Name:         awssdk.StringValue(function.FunctionName),
+				Arn:          awssdk.StringValue(function.FunctionArn),
+				Runtime:      awssdk.StringValue(function.Runtime),
+				Handler:      awssdk.StringValue(function.Handler),
+				MemorySize:   awssdk.Int64Value(function.MemorySize),
+				Timeout:      awssdk.Int64Value(function.Timeout),
+				CodeSize:     awssdk.Int64Value(function.CodeSize),
+				LastModified: awssdk.StringValue(function.LastModified),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		logs.Error.Print("Could not list Lambda functions.")
+		return nil, err
+	}
+	return functions, nil
+}
+
+// LambdaFunctionByName returns the configuration of a function whose environment
+// variables are redacted, or nil when it does not exist
+func LambdaFunctionByName(name string) (*lambda.FunctionConfiguration, error) {
+	res, err := lambdaClient().GetFunctionConfiguration(&lambda.GetFunctionConfigurationInput{FunctionName: awssdk.String(name)})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == lambda.ErrCodeResourceNotFoundException {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lambdaRedact(res), nil
+}
+
+// LambdaInvoke invokes a function synchronously with the payload,
+// and returns what it returned along with the tail of its log.
+// Errors of the function itself are told by FunctionError.
+func LambdaInvoke(name, qualifier string, payload []byte) (*LambdaInvocation, error) {
+	req := &lambda.InvokeInput{
+		FunctionName:   awssdk.String(name),
+		InvocationType: awssdk.String(lambda.InvocationTypeRequestResponse),
+		LogType:        awssdk.String(lambda.LogTypeTail),
+		Payload:        payload,
+	}
+	if qualifier != "" {
+		req.Qualifier = awssdk.String(qualifier)
+	}
+	started := time.Now()
+	res, err := lambdaClient().Invoke(req)
+	if err != nil {
+		return nil, err
+	}
+	invocation := &LambdaInvocation{
+		StatusCode:      awssdk.Int64Value(res.StatusCode),
+		FunctionError:   awssdk.StringValue(res.FunctionError),
+		ExecutedVersion: awssdk.StringValue(res.ExecutedVersion),
+		Payload:         lambdaPayload(res.Payload),
+		RoundTrip:       float64(time.Since(started)) / float64(time.Millisecond),
+	}
+	if tail, err := base64.StdEncoding.DecodeString(awssdk.StringValue(res.LogResult)); err == nil {
+		invocation.LogTail = string(tail)
+	}
+	invocation.Duration, invocation.BilledDuration, invocation.InitDuration = lambdaDurations(invocation.LogTail)
+	return invocation, nil
+}
+
+// lambdaRedact returns a copy of the configuration whose environment
+// variables keep their keys but not their values
+func lambdaRedact(config *lambda.FunctionConfiguration) *lambda.FunctionConfiguration {
+	if config.Environment == nil {
+		return config
+	}
+	redacted := *config
+	redacted.Environment = &lambda.EnvironmentResponse{
+		Error:     config.Environment.Error,
+		Variables: map[string]*string{},
+	}
+	for key := range config.Environment.Variables {
+		redacted.Environment.Variables[key] = awssdk.String(LambdaRedacted)
+	}
+	return &redacted
+}
+
+func lambdaPayload(payload []byte) interface{} {
+	if len(payload) == 0 {
+		return nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(payload, &value); err != nil {
+		return string(payload)
+	}
+	return json.RawMessage(payload)
+}
+
+// lambdaDurations reads the durations Lambda reports at the end of the log
+func lambdaDurations(log string) (duration, billed, init float64) {
+	// read only the report, not what the function printed
+	idx := strings.LastIndex(log, "REPORT RequestId:")
+	if idx < 0 {
+		return 0, 0, 0
+	}
+	for _, match := range lambdaReport.FindAllStringSubmatch(log[idx:], -1) {
+		value, _ := strconv.ParseFloat(match[2], 64)
+		switch match[1] {
+		case "":
+			duration = value
+		case "Billed ":
+			billed = value
+		case "Init ":
+			init = value
+		}
+	}
+	return duration, billed, init
+}
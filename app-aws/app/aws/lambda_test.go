@@ -0,0 +1,145 @@
+package aws
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// fakeLambda points the lambda client to a local endpoint which answers
+// with the given function, and returns a function to restore the client
+func fakeLambda(handler func(w http.ResponseWriter, r *http.Request, body string) string) func() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(handler(w, r, string(body))))
+	}))
+	original := lambdaCfg
+	lambdaCfg = &awssdk.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    awssdk.String(server.URL),
+		Region:      awssdk.String("us-east-1"),
+		MaxRetries:  awssdk.Int(0),
+	}
+	return func() {
+		lambdaCfg = original
+		server.Close()
+	}
+}
+
+func TestLambdaFunctions(t *testing.T) {
+	defer fakeLambda(func(w http.ResponseWriter, r *http.Request, body string) string {
+		if r.URL.Query().Get("Marker") == "" {
+			return `{"Functions": [{"FunctionName": "resize", "Runtime": "nodejs4.3", "MemorySize": 256, ` +
+				`"Timeout": 30, "LastModified": "2016-01-01T00:00:00.000+0000"}], "NextMarker": "next"}`
+		}
+		return `{"Functions": [{"FunctionName": "thumbnail", "Runtime": "python2.7", "MemorySize": 128, "Timeout": 3}]}`
+	})()
+
+	actual, err := LambdaFunctions()
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if len(actual) != 2 {
+		t.Errorf("Expected %v functions, but got %v", 2, len(actual))
+		return
+	}
+	if f := actual[0]; f.Name != "resize" || f.Runtime != "nodejs4.3" || f.MemorySize != 256 || f.Timeout != 30 || f.LastModified == "" {
+		t.Errorf("Expected the function in the inventory, but got %v", f)
+	}
+	if actual[1].Name != "thumbnail" {
+		t.Errorf("Expected %v, but got %v", "thumbnail", actual[1].Name)
+	}
+}
+
+func TestLambdaFunctionByName(t *testing.T) {
+	defer fakeLambda(func(w http.ResponseWriter, r *http.Request, body string) string {
+		if strings.Contains(r.URL.Path, "/missing/") {
+			w.Header().Set("X-Amzn-Errortype", "ResourceNotFoundException")
+			w.WriteHeader(http.StatusNotFound)
+			return `{"Type": "User", "Message": "Function not found"}`
+		}
+		return `{"FunctionName": "resize", "Runtime": "nodejs4.3", ` +
+			`"Environment": {"Variables": {"DB_PASSWORD": "secret", "STAGE": "dev"}}}`
+	})()
+
+	actual, err := LambdaFunctionByName("resize")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if len(actual.Environment.Variables) != 2 {
+		t.Errorf("Expected %v environment keys, but got %v", 2, actual.Environment.Variables)
+	}
+	for key, value := range actual.Environment.Variables {
+		if *value != LambdaRedacted {
+			t.Errorf("Expected %v to be redacted, but got %v", key, *value)
+		}
+	}
+	missing, err := LambdaFunctionByName("missing")
+	if err != nil || missing != nil {
+		t.Errorf("Expected %v, but got %v, %v", nil, missing, err)
+	}
+}
+
+func TestLambdaInvoke(t *testing.T) {
+	var invoked *http.Request
+	var event map[string]interface{}
+	defer fakeLambda(func(w http.ResponseWriter, r *http.Request, body string) string {
+		invoked = r
+		json.Unmarshal([]byte(body), &event)
+		log := "START RequestId: 1\nhello\nEND RequestId: 1\n" +
+			"REPORT RequestId: 1\tDuration: 12.34 ms\tBilled Duration: 100 ms\tMemory Size: 128 MB\n"
+		w.Header().Set("X-Amz-Log-Result", base64.StdEncoding.EncodeToString([]byte(log)))
+		w.Header().Set("X-Amz-Executed-Version", "3")
+		return `{"resized": true}`
+	})()
+
+	actual, err := LambdaInvoke("resize", "live", []byte(`{"key": "a.png"}`))
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if invoked.Header.Get("X-Amz-Invocation-Type") != "RequestResponse" || invoked.Header.Get("X-Amz-Log-Type") != "Tail" {
+		t.Errorf("Expected a synchronous invocation with its log, but got %v", invoked.Header)
+	}
+	if invoked.URL.Query().Get("Qualifier") != "live" || event["key"] != "a.png" {
+		t.Errorf("Expected the payload to be passed to live, but got %v and %v", invoked.URL, event)
+	}
+	if payload, ok := actual.Payload.(json.RawMessage); !ok || string(payload) != `{"resized": true}` {
+		t.Errorf("Expected %v, but got %v", `{"resized": true}`, actual.Payload)
+	}
+	if !strings.Contains(actual.LogTail, "hello") || actual.ExecutedVersion != "3" {
+		t.Errorf("Expected the log tail, but got %v", actual.LogTail)
+	}
+	if actual.Duration != 12.34 || actual.BilledDuration != 100 {
+		t.Errorf("Expected %v and %v, but got %v and %v", 12.34, 100, actual.Duration, actual.BilledDuration)
+	}
+}
+
+func TestLambdaDurations(t *testing.T) {
+	log := "START RequestId: 1 Version: $LATEST\nprinted\tDuration: 999 ms by the function\nEND RequestId: 1\n" +
+		"REPORT RequestId: 1\tDuration: 12.34 ms\tBilled Duration: 13 ms\tMemory Size: 128 MB\t" +
+		"Max Memory Used: 64 MB\tInit Duration: 150.12 ms\t\n"
+	duration, billed, init := lambdaDurations(log)
+	if duration != 12.34 || billed != 13 || init != 150.12 {
+		t.Errorf("Expected %v, %v and %v, but got %v, %v and %v", 12.34, 13, 150.12, duration, billed, init)
+	}
+}
+
+func TestLambdaPayload(t *testing.T) {
+	if actual := lambdaPayload([]byte("not a json")); actual != "not a json" {
+		t.Errorf("Expected %v, but got %v", "not a json", actual)
+	}
+	if actual := lambdaPayload(nil); actual != nil {
+		t.Errorf("Expected %v, but got %v", nil, actual)
+	}
+}
@@ -0,0 +1,242 @@
+package aws
+
+/**
+ * @see https://github.com/aws/aws-sdk-go/blob/master/service/rds/api.go
+ */
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/rds"
+	appcfg "github.com/pottava/golang-microservices/app-aws/app/config"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+)
+
+var rdsCfg *awssdk.Config
+
+func init() {
+	rdsCfg = config()
+}
+
+func rdsClient() *rds.RDS {
+	return rds.New(newSession(), rdsCfg)
+}
+
+// ErrNoSuchDBInstance means that the DB instance does not exist
+var ErrNoSuchDBInstance = errors.New("the DB instance does not exist")
+
+// ErrProduction means that the database is not known to be a non-production one,
+// which the service does not start or stop
+var ErrProduction = errors.New("only non-production databases can be started or stopped")
+
+// rdsNonProductionEnvs are values of "Env" or "Environment" tags of
+// databases which can be started and stopped
+var rdsNonProductionEnvs = map[string]bool{
+	"dev": true, "development": true, "test": true, "testing": true,
+	"qa": true, "stage": true, "staging": true, "sandbox": true,
+}
+
+// RdsInstance represents a DB instance with whether it can be started and stopped,
+// which is the case only when it is tagged as a non-production one or allowed
+// by APP_RDS_STOPPABLE
+type RdsInstance struct {
+	*rds.DBInstance
+	NonProduction bool `json:"NonProduction"`
+}
+
+// RdsSnapshot represents a DB snapshot with its age
+type RdsSnapshot struct {
+	*rds.DBSnapshot
+	AgeDays int `json:"AgeDays"`
+}
+
+// RdsInstances returns DB instances
+func RdsInstances() (instances []*RdsInstance, e error) {
+	return rdsInstances(&rds.DescribeDBInstancesInput{})
+}
+
+// RdsInstanceByID returns a specified DB instance, or nil when it does not exist
+func RdsInstanceByID(id string) (instance *RdsInstance, e error) {
+	instances, err := rdsInstances(&rds.DescribeDBInstancesInput{DBInstanceIdentifier: awssdk.String(id)})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == rds.ErrCodeDBInstanceNotFoundFault {
+		return nil, nil
+	}
+	if err != nil || len(instances) == 0 {
+		return nil, err
+	}
+	return instances[0], nil
+}
+
+func rdsInstances(req *rds.DescribeDBInstancesInput) (instances []*RdsInstance, e error) {
+	stoppable := map[string]bool{}
+	for _, id := range appcfg.NewConfig().RdsStoppable {
+		stoppable[strings.TrimSpace(id)] = true
+	}
+	instances = []*RdsInstance{}
+	err := rdsClient().DescribeDBInstancesPages(req, func(res *rds.DescribeDBInstancesOutput, last bool) bool {
+		for _, instance := range res.DBInstances {
+			instances = append(instances, &RdsInstance{
+				DBInstance:    instance,
+				NonProduction: stoppable[awssdk.StringValue(instance.DBInstanceIdentifier)] || rdsNonProduction(instance.TagList),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		logs.Error.Print("Could not describe DB instances.")
+		return nil, err
+	}
+	return instances, nil
+}
+
+// RdsClusters returns DB clusters
+func RdsClusters() (clusters []*rds.DBCluster, e error) {
+	clusters = []*rds.DBCluster{}
+	err := rdsClient().DescribeDBClustersPages(&rds.DescribeDBClustersInput{}, func(res *rds.DescribeDBClustersOutput, last bool) bool {
+		clusters = append(clusters, res.DBClusters...)
+		return true
+	})
+	if err != nil {
+		logs.Error.Print("Could not describe DB clusters.")
+		return nil, err
+	}
+	return clusters, nil
+}
+
+// RdsClusterByID returns a specified DB cluster, or nil when it does not exist
+func RdsClusterByID(id string) (*rds.DBCluster, error) {
+	res, err := rdsClient().DescribeDBClusters(&rds.DescribeDBClustersInput{DBClusterIdentifier: awssdk.String(id)})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == rds.ErrCodeDBClusterNotFoundFault {
+		return nil, nil
+	}
+	if err != nil || len(res.DBClusters) == 0 {
+		return nil, err
+	}
+	return res.DBClusters[0], nil
+}
+
+// RdsStartInstance starts a stopped DB instance if it is a non-production one
+func RdsStartInstance(id string) (instance *rds.DBInstance, e error) {
+	if err := rdsStoppable(id); err != nil {
+		return nil, err
+	}
+	res, err := rdsClient().StartDBInstance(&rds.StartDBInstanceInput{DBInstanceIdentifier: awssdk.String(id)})
+	if err != nil {
+		return nil, err
+	}
+	return res.DBInstance, nil
+}
+
+// RdsStopInstance stops a DB instance if it is a non-production one
+func RdsStopInstance(id string) (instance *rds.DBInstance, e error) {
+	if err := rdsStoppable(id); err != nil {
+		return nil, err
+	}
+	res, err := rdsClient().StopDBInstance(&rds.StopDBInstanceInput{DBInstanceIdentifier: awssdk.String(id)})
+	if err != nil {
+		return nil, err
+	}
+	return res.DBInstance, nil
+}
+
+// RdsCreateSnapshot creates a manual snapshot of a DB instance.
+// Its identifier is generated from the instance and the time when it is empty.
+func RdsCreateSnapshot(id, snapshotID string) (snapshot *rds.DBSnapshot, e error) {
+	if snapshotID == "" {
+		snapshotID = id + "-manual-" + time.Now().UTC().Format("20060102150405")
+	}
+	res, err := rdsClient().CreateDBSnapshot(&rds.CreateDBSnapshotInput{
+		DBInstanceIdentifier: awssdk.String(id),
+		DBSnapshotIdentifier: awssdk.String(snapshotID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.DBSnapshot, nil
+}
+
+// RdsSnapshots returns DB snapshots, oldest first. They can be narrowed down
+// to the ones of an instance and to the ones older than a duration.
+func RdsSnapshots(instanceID string, olderThan time.Duration) (snapshots []*RdsSnapshot, e error) {
+	req := &rds.DescribeDBSnapshotsInput{}
+	if instanceID != "" {
+		req.DBInstanceIdentifier = awssdk.String(instanceID)
+	}
+	list := []*rds.DBSnapshot{}
+	err := rdsClient().DescribeDBSnapshotsPages(req, func(res *rds.DescribeDBSnapshotsOutput, last bool) bool {
+		list = append(list, res.DBSnapshots...)
+		return true
+	})
+	if err != nil {
+		logs.Error.Print("Could not describe DB snapshots.")
+		return nil, err
+	}
+	return rdsSnapshotsByAge(list, olderThan, time.Now()), nil
+}
+
+// RdsSnapshotByID returns a specified DB snapshot, or nil when it does not exist
+func RdsSnapshotByID(id string) (snapshot *RdsSnapshot, e error) {
+	res, err := rdsClient().DescribeDBSnapshots(&rds.DescribeDBSnapshotsInput{DBSnapshotIdentifier: awssdk.String(id)})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == rds.ErrCodeDBSnapshotNotFoundFault {
+		return nil, nil
+	}
+	if err != nil || len(res.DBSnapshots) == 0 {
+		return nil, err
+	}
+	snapshot = &RdsSnapshot{DBSnapshot: res.DBSnapshots[0]}
+	if created := res.DBSnapshots[0].SnapshotCreateTime; created != nil {
+		snapshot.AgeDays = int(time.Since(*created).Hours() / 24)
+	}
+	return snapshot, nil
+}
+
+func rdsSnapshotsByAge(list []*rds.DBSnapshot, olderThan time.Duration, now time.Time) []*RdsSnapshot {
+	snapshots := []*RdsSnapshot{}
+	for _, snapshot := range list {
+		// snapshots being created have no creation time yet
+		if snapshot.SnapshotCreateTime == nil {
+			continue
+		}
+		age := now.Sub(*snapshot.SnapshotCreateTime)
+		if age < olderThan {
+			continue
+		}
+		snapshots = append(snapshots, &RdsSnapshot{DBSnapshot: snapshot, AgeDays: int(age.Hours() / 24)})
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].SnapshotCreateTime.Before(*snapshots[j].SnapshotCreateTime)
+	})
+	return snapshots
+}
+
+func rdsStoppable(id string) error {
+	instance, err := RdsInstanceByID(id)
+	if err != nil {
+		return err
+	}
+	if instance == nil {
+		return ErrNoSuchDBInstance
+	}
+	if !instance.NonProduction {
+		return ErrProduction
+	}
+	return nil
+}
+
+// rdsNonProduction tells if an "Env" or "Environment" tag says non-production.
+// Databases without such tags are treated as production ones.
+func rdsNonProduction(tags []*rds.Tag) bool {
+	for _, tag := range tags {
+		switch strings.ToLower(awssdk.StringValue(tag.Key)) {
+		case "env", "environment":
+			if rdsNonProductionEnvs[strings.ToLower(strings.TrimSpace(awssdk.StringValue(tag.Value)))] {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package aws
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+)
+
+func rdsInstanceXML(id, env string) string {
+	tags := ""
+	if env != "" {
+		tags = `<TagList><Tag><Key>Env</Key><Value>` + env + `</Value></Tag></TagList>`
+	}
+	return `<DescribeDBInstancesResponse><DescribeDBInstancesResult><DBInstances><DBInstance>` +
+		`<DBInstanceIdentifier>` + id + `</DBInstanceIdentifier><Engine>postgres</Engine>` + tags +
+		`</DBInstance></DBInstances></DescribeDBInstancesResult></DescribeDBInstancesResponse>`
+}
+
+func TestRdsStopInstance(t *testing.T) {
+	original := os.Getenv("APP_RDS_STOPPABLE")
+	os.Setenv("APP_RDS_STOPPABLE", "batch-db")
+	defer os.Setenv("APP_RDS_STOPPABLE", original)
+
+	stopped := []string{}
+	defer fakeQueryEndpoint(&rdsCfg, func(action string, form url.Values) (int, string) {
+		id := form.Get("DBInstanceIdentifier")
+		switch action {
+		case "DescribeDBInstances":
+			switch id {
+			case "prod-db":
+				return http.StatusOK, rdsInstanceXML(id, "Production")
+			case "dev-db":
+				return http.StatusOK, rdsInstanceXML(id, "dev")
+			case "missing-db":
+				return http.StatusNotFound, `<ErrorResponse><Error><Type>Sender</Type><Code>DBInstanceNotFound</Code>` +
+					`<Message>DBInstance missing-db not found.</Message></Error></ErrorResponse>`
+			}
+			return http.StatusOK, rdsInstanceXML(id, "")
+		case "StopDBInstance":
+			stopped = append(stopped, id)
+			return http.StatusOK, `<StopDBInstanceResponse><StopDBInstanceResult><DBInstance>` +
+				`<DBInstanceIdentifier>` + id + `</DBInstanceIdentifier><DBInstanceStatus>stopping</DBInstanceStatus>` +
+				`</DBInstance></StopDBInstanceResult></StopDBInstanceResponse>`
+		}
+		return http.StatusBadRequest, ""
+	})()
+
+	// databases without an environment tag are treated as production ones
+	for _, id := range []string{"prod-db", "untagged-db"} {
+		if _, err := RdsStopInstance(id); err != ErrProduction {
+			t.Errorf("Expected %v for %v, but got %v", ErrProduction, id, err)
+		}
+	}
+	if _, err := RdsStopInstance("missing-db"); err != ErrNoSuchDBInstance {
+		t.Errorf("Expected %v, but got %v", ErrNoSuchDBInstance, err)
+	}
+	if len(stopped) != 0 {
+		t.Errorf("Expected no databases to be stopped, but got %v", stopped)
+	}
+	actual, err := RdsStopInstance("dev-db")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if *actual.DBInstanceStatus != "stopping" {
+		t.Errorf("Expected dev-db to be stopping, but got %v", actual)
+	}
+	if _, err = RdsStopInstance("batch-db"); err != nil {
+		t.Errorf("Expected an allowed database to be stopped, but got %v", err)
+	}
+	if len(stopped) != 2 || stopped[0] != "dev-db" || stopped[1] != "batch-db" {
+		t.Errorf("Expected dev-db and batch-db to be stopped, but got %v", stopped)
+	}
+}
+
+func TestRdsClusterByID(t *testing.T) {
+	defer fakeQueryEndpoint(&rdsCfg, func(action string, form url.Values) (int, string) {
+		if id := form.Get("DBClusterIdentifier"); id != "aurora-1" {
+			return http.StatusNotFound, `<ErrorResponse><Error><Type>Sender</Type><Code>DBClusterNotFoundFault</Code>` +
+				`<Message>DBCluster ` + id + ` not found.</Message></Error></ErrorResponse>`
+		}
+		return http.StatusOK, `<DescribeDBClustersResponse><DescribeDBClustersResult><DBClusters><DBCluster>` +
+			`<DBClusterIdentifier>aurora-1</DBClusterIdentifier><Status>available</Status>` +
+			`</DBCluster></DBClusters></DescribeDBClustersResult></DescribeDBClustersResponse>`
+	})()
+
+	actual, err := RdsClusterByID("aurora-1")
+	if err != nil || actual == nil || *actual.Status != "available" {
+		t.Errorf("Expected aurora-1 to be available, but got %v, %v", actual, err)
+	}
+	missing, err := RdsClusterByID("aurora-2")
+	if err != nil || missing != nil {
+		t.Errorf("Expected %v, but got %v, %v", nil, missing, err)
+	}
+}
+
+func TestRdsSnapshotByID(t *testing.T) {
+	defer fakeQueryEndpoint(&rdsCfg, func(action string, form url.Values) (int, string) {
+		if form.Get("DBSnapshotIdentifier") != "db-1-manual" {
+			return http.StatusNotFound, `<ErrorResponse><Error><Type>Sender</Type><Code>DBSnapshotNotFound</Code>` +
+				`<Message>DBSnapshot not found.</Message></Error></ErrorResponse>`
+		}
+		return http.StatusOK, `<DescribeDBSnapshotsResponse><DescribeDBSnapshotsResult><DBSnapshots><DBSnapshot>` +
+			`<DBSnapshotIdentifier>db-1-manual</DBSnapshotIdentifier><DBInstanceIdentifier>db-1</DBInstanceIdentifier>` +
+			`</DBSnapshot></DBSnapshots></DescribeDBSnapshotsResult></DescribeDBSnapshotsResponse>`
+	})()
+
+	actual, err := RdsSnapshotByID("db-1-manual")
+	if err != nil || actual == nil || *actual.DBInstanceIdentifier != "db-1" {
+		t.Errorf("Expected the snapshot of db-1, but got %v, %v", actual, err)
+	}
+	if actual, err = RdsSnapshotByID("missing"); actual != nil || err != nil {
+		t.Errorf("Expected nothing, but got %v, %v", actual, err)
+	}
+}
+
+func TestRdsSnapshotsByAge(t *testing.T) {
+	now := time.Date(2016, 4, 1, 0, 0, 0, 0, time.UTC)
+	snapshot := func(id string, days int) *rds.DBSnapshot {
+		return &rds.DBSnapshot{DBSnapshotIdentifier: awssdk.String(id), SnapshotCreateTime: awssdk.Time(now.AddDate(0, 0, -days))}
+	}
+	list := []*rds.DBSnapshot{
+		snapshot("new", 3),
+		snapshot("oldest", 90),
+		&rds.DBSnapshot{DBSnapshotIdentifier: awssdk.String("creating")},
+		snapshot("old", 40),
+	}
+	actual := rdsSnapshotsByAge(list, 30*24*time.Hour, now)
+	if len(actual) != 2 {
+		t.Errorf("Expected %v snapshots, but got %v", 2, len(actual))
+		return
+	}
+	if *actual[0].DBSnapshotIdentifier != "oldest" || actual[0].AgeDays != 90 || *actual[1].DBSnapshotIdentifier != "old" {
+		t.Errorf("Expected oldest and old, but got %v", actual)
+	}
+	if actual = rdsSnapshotsByAge(list, 0, now); len(actual) != 3 {
+		t.Errorf("Expected %v snapshots, but got %v", 3, len(actual))
+	}
+}
@@ -0,0 +1,365 @@
+package aws
+
+/**
+ * @see https://github.com/aws/aws-sdk-go/blob/master/service/route53/api.go
+ */
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+)
+
+var route53Cfg *awssdk.Config
+
+func init() {
+	route53Cfg = config()
+}
+
+func route53Client() *route53.Route53 {
+	return route53.New(newSession(), route53Cfg)
+}
+
+// How often a change is checked while waiting for it to propagate
+var route53PollInterval = 5 * time.Second
+
+// Route53DefaultTTL is used for record sets changed without their TTL
+const Route53DefaultTTL = 300
+
+// Route53MaxChanges is how many changes a batch can have
+const Route53MaxChanges = 1000
+
+var route53Types = map[string]bool{
+	"A": true, "AAAA": true, "CAA": true, "CNAME": true, "MX": true, "NAPTR": true,
+	"NS": true, "PTR": true, "SPF": true, "SRV": true, "TXT": true,
+}
+
+// Route53ChangeBatch is a set of changes applied to a hosted zone at once, e.g.
+// {"comment": "new web server", "changes": [{"action": "UPSERT",
+// "name": "www", "type": "A", "ttl": 60, "values": ["192.0.2.1"]}]}
+// Names without the trailing dot are relative to the zone unless they end
+// with the zone name, and "@" is the zone apex.
+type Route53ChangeBatch struct {
+	Comment string           `json:"comment,omitempty"`
+	Changes []*Route53Change `json:"changes"`
+}
+
+// Route53Change creates or updates (UPSERT), or deletes (DELETE) a record set.
+// DELETE can omit the TTL and the values to delete the current record set.
+type Route53Change struct {
+	Action string   `json:"action"`
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	TTL    *int64   `json:"ttl,omitempty"`
+	Values []string `json:"values"`
+}
+
+// Route53Zones returns hosted zones whose names contain the given one
+func Route53Zones(name string) (zones []*route53.HostedZone, e error) {
+	zones = []*route53.HostedZone{}
+	err := route53Client().ListHostedZonesPages(&route53.ListHostedZonesInput{}, func(res *route53.ListHostedZonesOutput, last bool) bool {
+		for _, zone := range res.HostedZones {
+			if route53Match(awssdk.StringValue(zone.Name), name) {
+				zone.Id = route53ID(zone.Id)
+				zones = append(zones, zone)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		logs.Error.Print("Could not list hosted zones.")
+		return nil, err
+	}
+	return zones, nil
+}
+
+// Route53Zone returns a hosted zone, or nil when it does not exist
+func Route53Zone(id string) (*route53.HostedZone, error) {
+	res, err := route53Client().GetHostedZone(&route53.GetHostedZoneInput{Id: awssdk.String(id)})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == route53.ErrCodeNoSuchHostedZone {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res.HostedZone.Id = route53ID(res.HostedZone.Id)
+	return res.HostedZone, nil
+}
+
+// Route53Records returns record sets of a hosted zone. They can be narrowed
+// down to the ones whose names contain the name, and to the ones of a type.
+func Route53Records(zoneID, name, recordType string) (records []*route53.ResourceRecordSet, e error) {
+	records = []*route53.ResourceRecordSet{}
+	err := route53Client().ListResourceRecordSetsPages(&route53.ListResourceRecordSetsInput{HostedZoneId: awssdk.String(zoneID)},
+		func(res *route53.ListResourceRecordSetsOutput, last bool) bool {
+			for _, record := range res.ResourceRecordSets {
+				if recordType != "" && !strings.EqualFold(awssdk.StringValue(record.Type), recordType) {
+					continue
+				}
+				if route53Match(awssdk.StringValue(record.Name), name) {
+					records = append(records, record)
+				}
+			}
+			return true
+		})
+	if err != nil {
+		logs.Error.Printf("Could not list record sets of %s.", zoneID)
+		return nil, err
+	}
+	return records, nil
+}
+
+// Normalize makes names fully qualified in the zone and types upper case,
+// quotes TXT values, and sets the default TTL where UPSERT omits it
+func (batch *Route53ChangeBatch) Normalize(zone string) {
+	zone = route53Fqdn(zone)
+	for _, change := range batch.Changes {
+		if change == nil {
+			continue
+		}
+		change.Action = strings.ToUpper(strings.TrimSpace(change.Action))
+		change.Type = strings.ToUpper(strings.TrimSpace(change.Type))
+		change.Name = route53Qualify(change.Name, zone)
+		if change.TTL == nil && change.Action == route53.ChangeActionUpsert {
+			change.TTL = awssdk.Int64(Route53DefaultTTL)
+		}
+		if change.Type == "TXT" || change.Type == "SPF" {
+			for idx, value := range change.Values {
+				if !strings.HasPrefix(value, `"`) {
+					change.Values[idx] = `"` + strings.Replace(value, `"`, `\"`, -1) + `"`
+				}
+			}
+		}
+	}
+}
+
+// Complete fills the TTL and the values of DELETE changes which omit them
+// with the current record sets, and returns problems of the ones which can not be
+func (batch *Route53ChangeBatch) Complete(zoneID string) ([]string, error) {
+	problems := []string{}
+	for idx, change := range batch.Changes {
+		if change == nil || change.Action != route53.ChangeActionDelete || (change.TTL != nil && len(change.Values) > 0) {
+			continue
+		}
+		field := fmt.Sprintf("changes[%d]: ", idx)
+		current, err := route53RecordSet(zoneID, change.Name, change.Type)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case current == nil:
+			problems = append(problems, field+change.Name+" "+change.Type+" does not exist")
+		case current.AliasTarget != nil || current.SetIdentifier != nil:
+			problems = append(problems, field+change.Name+" "+change.Type+" is an alias or has a routing policy, which is not supported")
+		default:
+			if change.TTL == nil {
+				change.TTL = current.TTL
+			}
+			if len(change.Values) == 0 {
+				for _, record := range current.ResourceRecords {
+					change.Values = append(change.Values, awssdk.StringValue(record.Value))
+				}
+			}
+		}
+	}
+	return problems, nil
+}
+
+// Validate returns problems of the batch as "changes[index]: message"
+func (batch *Route53ChangeBatch) Validate(zone string) []string {
+	zone = route53Fqdn(zone)
+	problems := []string{}
+	if len(batch.Changes) == 0 || len(batch.Changes) > Route53MaxChanges {
+		problems = append(problems, fmt.Sprintf("changes: must have between 1 and %d changes", Route53MaxChanges))
+	}
+	changed := map[string]int{}
+	for idx, change := range batch.Changes {
+		field := fmt.Sprintf("changes[%d]: ", idx)
+		if change == nil {
+			problems = append(problems, field+"is empty")
+			continue
+		}
+		if change.Action != route53.ChangeActionUpsert && change.Action != route53.ChangeActionDelete {
+			problems = append(problems, field+"action must be UPSERT or DELETE")
+		}
+		if change.Name != zone && !strings.HasSuffix(change.Name, "."+zone) {
+			problems = append(problems, field+change.Name+" is not in "+zone)
+		}
+		if !route53Types[change.Type] {
+			problems = append(problems, field+change.Type+" is not a supported type")
+		}
+		if change.TTL == nil {
+			problems = append(problems, field+"ttl is required")
+		} else if *change.TTL < 0 || *change.TTL > 2147483647 {
+			problems = append(problems, field+"ttl must be between 0 and 2147483647")
+		}
+		if len(change.Values) == 0 {
+			problems = append(problems, field+"values are required")
+		}
+		for _, value := range change.Values {
+			if problem := route53ValidateValue(change.Type, value); problem != "" {
+				problems = append(problems, field+problem)
+			}
+		}
+		if change.Type == "CNAME" && len(change.Values) > 1 {
+			problems = append(problems, field+"CNAME must have only one value")
+		}
+		if change.Type == "CNAME" && change.Name == zone {
+			problems = append(problems, field+"CNAME can not be at the zone apex")
+		}
+		key := change.Name + " " + change.Type
+		if prev, found := changed[key]; found {
+			problems = append(problems, fmt.Sprintf("%s%s is changed by changes[%d] too", field, key, prev))
+		} else {
+			changed[key] = idx
+		}
+	}
+	return problems
+}
+
+func route53ValidateValue(recordType, value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "values must not be empty"
+	}
+	switch recordType {
+	case "A":
+		if ip := net.ParseIP(value); ip == nil || ip.To4() == nil {
+			return value + " is not an IPv4 address"
+		}
+	case "AAAA":
+		if ip := net.ParseIP(value); ip == nil || ip.To4() != nil {
+			return value + " is not an IPv6 address"
+		}
+	}
+	return ""
+}
+
+// Route53ApplyChanges applies a validated batch to a hosted zone
+func Route53ApplyChanges(zoneID string, batch *Route53ChangeBatch) (*route53.ChangeInfo, error) {
+	changes := []*route53.Change{}
+	for _, change := range batch.Changes {
+		values := []*route53.ResourceRecord{}
+		for _, value := range change.Values {
+			values = append(values, &route53.ResourceRecord{Value: awssdk.String(value)})
+		}
+		changes = append(changes, &route53.Change{
+			Action: awssdk.String(change.Action),
+			ResourceRecordSet: &route53.ResourceRecordSet{
+				Name:            awssdk.String(change.Name),
+				Type:            awssdk.String(change.Type),
+				TTL:             change.TTL,
+				ResourceRecords: values,
+			},
+		})
+	}
+	req := &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: awssdk.String(zoneID),
+		ChangeBatch:  &route53.ChangeBatch{Changes: changes},
+	}
+	if batch.Comment != "" {
+		req.ChangeBatch.Comment = awssdk.String(batch.Comment)
+	}
+	res, err := route53Client().ChangeResourceRecordSets(req)
+	if err != nil {
+		return nil, err
+	}
+	res.ChangeInfo.Id = route53ID(res.ChangeInfo.Id)
+	return res.ChangeInfo, nil
+}
+
+// Route53ChangeStatus returns a change, whose status is PENDING
+// until it has propagated to all the name servers and INSYNC after that
+func Route53ChangeStatus(id string) (*route53.ChangeInfo, error) {
+	res, err := route53Client().GetChange(&route53.GetChangeInput{Id: awssdk.String(id)})
+	if err != nil {
+		return nil, err
+	}
+	res.ChangeInfo.Id = route53ID(res.ChangeInfo.Id)
+	return res.ChangeInfo, nil
+}
+
+// Route53WaitForChange waits for a change to propagate at most for the timeout,
+// and returns its status then
+func Route53WaitForChange(id string, timeout time.Duration) (*route53.ChangeInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := route53Client().WaitUntilResourceRecordSetsChangedWithContext(ctx,
+		&route53.GetChangeInput{Id: awssdk.String(id)},
+		request.WithWaiterDelay(request.ConstantWaiterDelay(route53PollInterval)))
+	if err != nil && ctx.Err() == nil {
+		return nil, err
+	}
+	return Route53ChangeStatus(id)
+}
+
+// route53RecordSet returns the record set of the name and the type, or nil when it does not exist
+func route53RecordSet(zoneID, name, recordType string) (*route53.ResourceRecordSet, error) {
+	res, err := route53Client().ListResourceRecordSets(&route53.ListResourceRecordSetsInput{
+		HostedZoneId:    awssdk.String(zoneID),
+		StartRecordName: awssdk.String(name),
+		StartRecordType: awssdk.String(recordType),
+		MaxItems:        awssdk.String("1"),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range res.ResourceRecordSets {
+		found := strings.Replace(strings.ToLower(awssdk.StringValue(record.Name)), `\052`, "*", -1)
+		if found == name && awssdk.StringValue(record.Type) == recordType {
+			return record, nil
+		}
+	}
+	return nil, nil
+}
+
+// route53ID strips the "/hostedzone/" or "/change/" prefix Route 53 returns ids with
+func route53ID(id *string) *string {
+	if id == nil {
+		return nil
+	}
+	return awssdk.String((*id)[strings.LastIndex(*id, "/")+1:])
+}
+
+// route53Qualify makes a name fully qualified in the zone. Names with the trailing
+// dot are already, the ones which end with the zone name need only the dot,
+// and the others are relative to the zone.
+func route53Qualify(name, zone string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	apex := strings.TrimSuffix(zone, ".")
+	switch {
+	case name == "@":
+		return zone
+	case name == "" || strings.HasSuffix(name, "."):
+		return name
+	case name == apex || strings.HasSuffix(name, "."+apex):
+		return name + "."
+	}
+	return name + "." + zone
+}
+
+// route53Fqdn makes a name lower case with its trailing dot, as Route 53 returns them
+func route53Fqdn(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	return name
+}
+
+// route53Match tells if a name contains the searched one, where Route 53
+// returns "*" of wildcard records as "\052"
+func route53Match(name, search string) bool {
+	if search == "" {
+		return true
+	}
+	name = strings.Replace(strings.ToLower(name), `\052`, "*", -1)
+	return strings.Contains(name, strings.TrimSuffix(strings.ToLower(search), "."))
+}
@@ -0,0 +1,231 @@
+package aws
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// fakeRoute53 points the route53 client to a local endpoint which answers
+// with the given function, and returns a function to restore the client
+func fakeRoute53(handler func(r *http.Request, body string) string) func() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/xml")
+		w.Write([]byte(handler(r, string(body))))
+	}))
+	original := route53Cfg
+	route53Cfg = &awssdk.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    awssdk.String(server.URL),
+		Region:      awssdk.String("us-east-1"),
+		MaxRetries:  awssdk.Int(0),
+	}
+	return func() {
+		route53Cfg = original
+		server.Close()
+	}
+}
+
+func route53RecordXML(name, recordType, value string) string {
+	return `<ResourceRecordSet><Name>` + name + `</Name><Type>` + recordType + `</Type><TTL>300</TTL>` +
+		`<ResourceRecords><ResourceRecord><Value>` + value + `</Value></ResourceRecord></ResourceRecords></ResourceRecordSet>`
+}
+
+func TestRoute53Records(t *testing.T) {
+	defer fakeRoute53(func(r *http.Request, body string) string {
+		if r.URL.Query().Get("name") == "" {
+			return `<ListResourceRecordSetsResponse><ResourceRecordSets>` +
+				route53RecordXML("example.com.", "NS", "ns-1.awsdns-00.com.") +
+				route53RecordXML("www.example.com.", "A", "192.0.2.1") +
+				`</ResourceRecordSets><IsTruncated>true</IsTruncated><MaxItems>2</MaxItems>` +
+				`<NextRecordName>www.example.com.</NextRecordName><NextRecordType>CNAME</NextRecordType>` +
+				`</ListResourceRecordSetsResponse>`
+		}
+		return `<ListResourceRecordSetsResponse><ResourceRecordSets>` +
+			route53RecordXML("www.example.com.", "CNAME", "lb.example.com.") +
+			route53RecordXML(`\052.www.example.com.`, "A", "192.0.2.2") +
+			`</ResourceRecordSets><IsTruncated>false</IsTruncated><MaxItems>2</MaxItems></ListResourceRecordSetsResponse>`
+	})()
+
+	actual, err := Route53Records("Z1", "WWW", "a")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	expected := []string{"www.example.com.", `\052.www.example.com.`}
+	if len(actual) != len(expected) {
+		t.Errorf("Expected %v record sets, but got %v", len(expected), actual)
+		return
+	}
+	for idx, record := range actual {
+		if *record.Name != expected[idx] {
+			t.Errorf("Expected %v, but got %v", expected[idx], *record.Name)
+		}
+	}
+	if actual, _ = Route53Records("Z1", "*.www", ""); len(actual) != 1 {
+		t.Errorf("Expected the wildcard record set, but got %v", actual)
+	}
+}
+
+func TestRoute53ChangeBatchValidate(t *testing.T) {
+	batch := &Route53ChangeBatch{Changes: []*Route53Change{
+		{Action: "upsert", Name: "www.example.com", Type: "a", Values: []string{"192.0.2.1"}},
+		{Action: "DELETE", Name: "Old.Example.com.", Type: "TXT", TTL: awssdk.Int64(60), Values: []string{"v=spf1 -all"}},
+		{Action: "UPSERT", Name: "api", Type: "CNAME", TTL: awssdk.Int64(0), Values: []string{"lb.example.net."}},
+		{Action: "UPSERT", Name: "@", Type: "MX", Values: []string{"10 mail.example.com."}},
+	}}
+	batch.Normalize("example.com.")
+	if problems := batch.Validate("example.com."); len(problems) != 0 {
+		t.Errorf("Expected no problems, but got %v", problems)
+	}
+	if change := batch.Changes[0]; change.Action != "UPSERT" || change.Name != "www.example.com." || *change.TTL != Route53DefaultTTL {
+		t.Errorf("Expected the change to be normalized, but got %v", change)
+	}
+	if change := batch.Changes[1]; change.Name != "old.example.com." || change.Values[0] != `"v=spf1 -all"` {
+		t.Errorf("Expected the change to be normalized, but got %v", change)
+	}
+	// relative names are in the zone, and a TTL of 0 is kept
+	if change := batch.Changes[2]; change.Name != "api.example.com." || *change.TTL != 0 {
+		t.Errorf("Expected api.example.com. with a TTL of 0, but got %v %v", change.Name, *change.TTL)
+	}
+	if change := batch.Changes[3]; change.Name != "example.com." {
+		t.Errorf("Expected %v, but got %v", "example.com.", change.Name)
+	}
+
+	invalid := &Route53ChangeBatch{Changes: []*Route53Change{
+		{Action: "CREATE", Name: "www.example.org.", Type: "A", Values: []string{"2001:db8::1"}},
+		{Action: "UPSERT", Name: "example.com.", Type: "CNAME", Values: []string{"a.example.net.", "b.example.net."}},
+		{Action: "UPSERT", Name: "example.com.", Type: "CNAME", Values: []string{"a.example.net."}},
+		{Action: "UPSERT", Name: "mail.example.com.", Type: "ALIAS"},
+	}}
+	invalid.Normalize("example.com.")
+	expected := []string{
+		"changes[0]: action must be UPSERT or DELETE",
+		"changes[0]: www.example.org. is not in example.com.",
+		"changes[0]: ttl is required",
+		"changes[0]: 2001:db8::1 is not an IPv4 address",
+		"changes[1]: CNAME must have only one value",
+		"changes[1]: CNAME can not be at the zone apex",
+		"changes[2]: CNAME can not be at the zone apex",
+		"changes[2]: example.com. CNAME is changed by changes[1] too",
+		"changes[3]: ALIAS is not a supported type",
+		"changes[3]: values are required",
+	}
+	actual := invalid.Validate("example.com.")
+	if len(actual) != len(expected) {
+		t.Errorf("Expected %v, but got %v", expected, actual)
+		return
+	}
+	for idx, problem := range actual {
+		if problem != expected[idx] {
+			t.Errorf("Expected %v, but got %v", expected[idx], problem)
+		}
+	}
+	if problems := (&Route53ChangeBatch{}).Validate("example.com."); len(problems) != 1 {
+		t.Errorf("Expected an empty batch to be invalid, but got %v", problems)
+	}
+}
+
+func TestRoute53ApplyChanges(t *testing.T) {
+	original := route53PollInterval
+	route53PollInterval = time.Millisecond
+	defer func() { route53PollInterval = original }()
+
+	sent, polls := "", 0
+	defer fakeRoute53(func(r *http.Request, body string) string {
+		if r.Method == http.MethodPost {
+			sent = body
+			return `<ChangeResourceRecordSetsResponse><ChangeInfo><Id>/change/C1</Id>` +
+				`<Status>PENDING</Status><SubmittedAt>2016-01-01T00:00:00Z</SubmittedAt></ChangeInfo></ChangeResourceRecordSetsResponse>`
+		}
+		polls++
+		status := "PENDING"
+		if polls > 2 {
+			status = "INSYNC"
+		}
+		return `<GetChangeResponse><ChangeInfo><Id>/change/C1</Id><Status>` + status + `</Status>` +
+			`<SubmittedAt>2016-01-01T00:00:00Z</SubmittedAt></ChangeInfo></GetChangeResponse>`
+	})()
+
+	batch := &Route53ChangeBatch{Comment: "new web server", Changes: []*Route53Change{
+		{Action: "UPSERT", Name: "www.example.com.", Type: "A", TTL: awssdk.Int64(60), Values: []string{"192.0.2.1", "192.0.2.2"}},
+	}}
+	change, err := Route53ApplyChanges("Z1", batch)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	for _, expected := range []string{"<Action>UPSERT</Action>", "<TTL>60</TTL>", "<Value>192.0.2.2</Value>", "<Comment>new web server</Comment>"} {
+		if !strings.Contains(sent, expected) {
+			t.Errorf("Expected %v to be sent, but got %v", expected, sent)
+		}
+	}
+	if *change.Status != "PENDING" || *change.Id != "C1" {
+		t.Errorf("Expected C1 to be PENDING, but got %v %v", *change.Id, *change.Status)
+	}
+	change, err = Route53WaitForChange(*change.Id, time.Second)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if *change.Status != "INSYNC" {
+		t.Errorf("Expected %v, but got %v", "INSYNC", *change.Status)
+	}
+}
+
+func TestRoute53ChangeBatchComplete(t *testing.T) {
+	defer fakeRoute53(func(r *http.Request, body string) string {
+		query := r.URL.Query()
+		if query.Get("name") == "www.example.com." && query.Get("type") == "A" {
+			return `<ListResourceRecordSetsResponse><ResourceRecordSets>` +
+				`<ResourceRecordSet><Name>www.example.com.</Name><Type>A</Type><TTL>60</TTL><ResourceRecords>` +
+				`<ResourceRecord><Value>192.0.2.1</Value></ResourceRecord><ResourceRecord><Value>192.0.2.2</Value></ResourceRecord>` +
+				`</ResourceRecords></ResourceRecordSet>` +
+				`</ResourceRecordSets><IsTruncated>false</IsTruncated><MaxItems>1</MaxItems></ListResourceRecordSetsResponse>`
+		}
+		// the next record set is returned when the searched one does not exist
+		return `<ListResourceRecordSetsResponse><ResourceRecordSets>` + route53RecordXML("www.example.com.", "TXT", `"hello"`) +
+			`</ResourceRecordSets><IsTruncated>false</IsTruncated><MaxItems>1</MaxItems></ListResourceRecordSetsResponse>`
+	})()
+
+	batch := &Route53ChangeBatch{Changes: []*Route53Change{
+		{Action: "DELETE", Name: "www", Type: "A"},
+		{Action: "DELETE", Name: "old", Type: "A"},
+		{Action: "UPSERT", Name: "new", Type: "A", Values: []string{"192.0.2.3"}},
+	}}
+	batch.Normalize("example.com.")
+	problems, err := batch.Complete("Z1")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if len(problems) != 1 || problems[0] != "changes[1]: old.example.com. A does not exist" {
+		t.Errorf("Expected old.example.com. not to exist, but got %v", problems)
+	}
+	if change := batch.Changes[0]; change.TTL == nil || *change.TTL != 60 || len(change.Values) != 2 {
+		t.Errorf("Expected the current TTL and values, but got %v", change)
+	}
+}
+
+func TestRoute53Qualify(t *testing.T) {
+	for name, expected := range map[string]string{
+		"www":              "www.example.com.",
+		"WWW.Example.com":  "www.example.com.",
+		"example.com":      "example.com.",
+		"@":                "example.com.",
+		"www.example.org.": "www.example.org.",
+		"*.dev":            "*.dev.example.com.",
+		"notexample.com":   "notexample.com.example.com.",
+	} {
+		if actual := route53Qualify(name, "example.com."); actual != expected {
+			t.Errorf("Expected %v, but got %v", expected, actual)
+		}
+	}
+}
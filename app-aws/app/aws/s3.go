@@ -0,0 +1,222 @@
+package aws
+
+/**
+ * @see https://github.com/aws/aws-sdk-go/blob/master/service/s3/api.go
+ */
+import (
+	"sync"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	appcfg "github.com/pottava/golang-microservices/app-aws/app/config"
+)
+
+var s3Cfg *awssdk.Config
+var s3Regions = map[string]string{}
+var s3RegionsMutex sync.Mutex
+
+func init() {
+	s3Cfg = config()
+	if endpoint := appcfg.NewConfig().AwsS3Endpoint; endpoint != "" {
+		s3Cfg.Endpoint = awssdk.String(endpoint)
+		s3Cfg.S3ForcePathStyle = awssdk.Bool(true)
+	}
+}
+
+func s3Client() *s3.S3 {
+	return s3.New(newSession(), s3Cfg)
+}
+
+// s3ClientFor returns a client for the region where the bucket is
+func s3ClientFor(bucket string) (*s3.S3, error) {
+	s3RegionsMutex.Lock()
+	region, found := s3Regions[bucket]
+	s3RegionsMutex.Unlock()
+
+	if !found {
+		res, err := s3Client().GetBucketLocation(&s3.GetBucketLocationInput{Bucket: awssdk.String(bucket)})
+		if err != nil {
+			return nil, err
+		}
+		// buckets in us-east-1 have no location constraint
+		region = s3.NormalizeBucketLocation(awssdk.StringValue(res.LocationConstraint))
+
+		s3RegionsMutex.Lock()
+		s3Regions[bucket] = region
+		s3RegionsMutex.Unlock()
+	}
+	return s3.New(newSession(), s3Cfg.Copy().WithRegion(region)), nil
+}
+
+// S3ObjectPage represents a page of objects in a bucket
+type S3ObjectPage struct {
+	Objects   []*s3.Object `json:"objects"`
+	Prefixes  []string     `json:"prefixes"`
+	NextToken string       `json:"nextToken,omitempty"`
+}
+
+// S3BucketSecurity summarizes who can access a bucket
+type S3BucketSecurity struct {
+	Bucket            string                             `json:"bucket"`
+	Region            string                             `json:"region"`
+	Policy            string                             `json:"policy,omitempty"`
+	PolicyPublic      bool                               `json:"policyPublic"`
+	PublicACL         []string                           `json:"publicAcl"`
+	PublicAccessBlock *s3.PublicAccessBlockConfiguration `json:"publicAccessBlock"`
+	Public            bool                               `json:"public"`
+}
+
+// S3Buckets returns buckets
+func S3Buckets() (buckets []*s3.Bucket, e error) {
+	res, err := s3Client().ListBuckets(&s3.ListBucketsInput{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Buckets, nil
+}
+
+// S3Objects returns a page of objects whose keys start with the prefix.
+// With a delimiter, keys are rolled up into common prefixes like directories.
+func S3Objects(bucket, prefix, delimiter, token string, limit int64) (page *S3ObjectPage, e error) {
+	client, err := s3ClientFor(bucket)
+	if err != nil {
+		return nil, err
+	}
+	req := &s3.ListObjectsV2Input{Bucket: awssdk.String(bucket)}
+	if prefix != "" {
+		req.Prefix = awssdk.String(prefix)
+	}
+	if delimiter != "" {
+		req.Delimiter = awssdk.String(delimiter)
+	}
+	if token != "" {
+		req.ContinuationToken = awssdk.String(token)
+	}
+	if limit > 0 {
+		req.MaxKeys = awssdk.Int64(limit)
+	}
+	res, err := client.ListObjectsV2(req)
+	if err != nil {
+		return nil, err
+	}
+	page = &S3ObjectPage{Objects: res.Contents, Prefixes: []string{}}
+	if page.Objects == nil {
+		page.Objects = []*s3.Object{}
+	}
+	for _, prefix := range res.CommonPrefixes {
+		page.Prefixes = append(page.Prefixes, awssdk.StringValue(prefix.Prefix))
+	}
+	if awssdk.BoolValue(res.IsTruncated) {
+		page.NextToken = awssdk.StringValue(res.NextContinuationToken)
+	}
+	return page, nil
+}
+
+// S3Object returns metadata of a specified object, or nil if it does not exist
+func S3Object(bucket, key string) (metadata *s3.HeadObjectOutput, e error) {
+	client, err := s3ClientFor(bucket)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.HeadObject(&s3.HeadObjectInput{
+		Bucket: awssdk.String(bucket),
+		Key:    awssdk.String(key),
+	})
+	if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == 404 {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// S3PresignedURL returns a URL to GET or PUT an object without credentials
+func S3PresignedURL(bucket, key, method string, expires time.Duration) (url string, e error) {
+	client, err := s3ClientFor(bucket)
+	if err != nil {
+		return "", err
+	}
+	if method == "PUT" {
+		req, _ := client.PutObjectRequest(&s3.PutObjectInput{
+			Bucket: awssdk.String(bucket),
+			Key:    awssdk.String(key),
+		})
+		return req.Presign(expires)
+	}
+	req, _ := client.GetObjectRequest(&s3.GetObjectInput{
+		Bucket: awssdk.String(bucket),
+		Key:    awssdk.String(key),
+	})
+	return req.Presign(expires)
+}
+
+// S3Security summarizes the bucket policy, ACL and public access block of a bucket
+func S3Security(bucket string) (security *S3BucketSecurity, e error) {
+	client, err := s3ClientFor(bucket)
+	if err != nil {
+		return nil, err
+	}
+	security = &S3BucketSecurity{Bucket: bucket, Region: awssdk.StringValue(client.Config.Region), PublicACL: []string{}}
+
+	policy, err := client.GetBucketPolicy(&s3.GetBucketPolicyInput{Bucket: awssdk.String(bucket)})
+	if err != nil && !s3NotConfigured(err) {
+		return nil, err
+	}
+	if err == nil {
+		security.Policy = awssdk.StringValue(policy.Policy)
+		status, err := client.GetBucketPolicyStatus(&s3.GetBucketPolicyStatusInput{Bucket: awssdk.String(bucket)})
+		if err != nil && !s3NotConfigured(err) {
+			return nil, err
+		}
+		if err == nil && status.PolicyStatus != nil {
+			security.PolicyPublic = awssdk.BoolValue(status.PolicyStatus.IsPublic)
+		}
+	}
+	acl, err := client.GetBucketAcl(&s3.GetBucketAclInput{Bucket: awssdk.String(bucket)})
+	if err != nil {
+		return nil, err
+	}
+	for _, grant := range acl.Grants {
+		if grant.Grantee == nil {
+			continue
+		}
+		switch awssdk.StringValue(grant.Grantee.URI) {
+		case "http://acs.amazonaws.com/groups/global/AllUsers", "http://acs.amazonaws.com/groups/global/AuthenticatedUsers":
+			security.PublicACL = append(security.PublicACL, awssdk.StringValue(grant.Grantee.URI)+" "+awssdk.StringValue(grant.Permission))
+		}
+	}
+	block, err := client.GetPublicAccessBlock(&s3.GetPublicAccessBlockInput{Bucket: awssdk.String(bucket)})
+	if err != nil && !s3NotConfigured(err) {
+		return nil, err
+	}
+	if err == nil {
+		security.PublicAccessBlock = block.PublicAccessBlockConfiguration
+	}
+	security.Public = s3Public(security)
+	return security, nil
+}
+
+// s3Public tells if the bucket is open to the public after the public access block is applied
+func s3Public(security *S3BucketSecurity) bool {
+	block := security.PublicAccessBlock
+	if block == nil {
+		block = &s3.PublicAccessBlockConfiguration{}
+	}
+	aclPublic := len(security.PublicACL) > 0 && !awssdk.BoolValue(block.IgnorePublicAcls)
+	policyPublic := security.PolicyPublic && !awssdk.BoolValue(block.RestrictPublicBuckets)
+	return aclPublic || policyPublic
+}
+
+// s3NotConfigured tells if the error means that the bucket simply lacks the configuration
+func s3NotConfigured(err error) bool {
+	if aerr, ok := err.(awserr.Error); ok {
+		switch aerr.Code() {
+		case "NoSuchBucketPolicy", "NoSuchPublicAccessBlockConfiguration":
+			return true
+		}
+	}
+	return false
+}
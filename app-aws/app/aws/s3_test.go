@@ -0,0 +1,178 @@
+package aws
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// fakeS3 points the s3 client to a local stand-in which serves the objects
+// of a bucket, and returns a function to restore the client
+func fakeS3(bucket string, objects map[string]string) func() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		path := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+		switch {
+		case path[0] == "":
+			fmt.Fprintf(w, `<ListAllMyBucketsResult><Buckets><Bucket><Name>%s</Name></Bucket></Buckets></ListAllMyBucketsResult>`, bucket)
+		case path[0] != bucket:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `<Error><Code>NoSuchBucket</Code><Message>NoSuchBucket</Message></Error>`)
+		case len(path) > 1:
+			content, found := objects[path[1]]
+			if !found {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+			w.Header().Set("Content-Type", "text/plain")
+		case r.URL.Query().Get("location") == "" && r.URL.Query()["location"] != nil:
+			fmt.Fprint(w, `<LocationConstraint>ap-northeast-1</LocationConstraint>`)
+		default:
+			fmt.Fprint(w, listObjects(objects, r.URL.Query().Get("prefix"), r.URL.Query().Get("delimiter"),
+				r.URL.Query().Get("continuation-token"), r.URL.Query().Get("max-keys")))
+		}
+	}))
+	original := s3Cfg
+	s3Cfg = &awssdk.Config{
+		Credentials:      credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:         awssdk.String(server.URL),
+		Region:           awssdk.String("us-east-1"),
+		S3ForcePathStyle: awssdk.Bool(true),
+		MaxRetries:       awssdk.Int(0),
+	}
+	s3Regions = map[string]string{}
+	return func() {
+		s3Cfg = original
+		server.Close()
+	}
+}
+
+func listObjects(objects map[string]string, prefix, delimiter, token, max string) string {
+	keys := []string{}
+	for key := range objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	limit := 1000
+	fmt.Sscan(max, &limit)
+	contents, prefixes, seen := "", "", map[string]bool{}
+	count, next, truncated := 0, "", false
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) || key <= token {
+			continue
+		}
+		if count == limit {
+			truncated = true
+			break
+		}
+		rest := key[len(prefix):]
+		if idx := strings.Index(rest, delimiter); delimiter != "" && idx >= 0 {
+			if common := prefix + rest[:idx+1]; !seen[common] {
+				seen[common] = true
+				prefixes += `<CommonPrefixes><Prefix>` + common + `</Prefix></CommonPrefixes>`
+			}
+			continue
+		}
+		contents += fmt.Sprintf(`<Contents><Key>%s</Key><Size>%d</Size></Contents>`, key, len(objects[key]))
+		count++
+		next = key
+	}
+	if !truncated {
+		next = ""
+	}
+	return fmt.Sprintf(`<ListBucketResult><IsTruncated>%v</IsTruncated><NextContinuationToken>%s</NextContinuationToken>%s%s</ListBucketResult>`,
+		truncated, next, contents, prefixes)
+}
+
+func TestS3Objects(t *testing.T) {
+	defer fakeS3("bucket", map[string]string{
+		"index.html":    "<html/>",
+		"logs/a.log":    "a",
+		"logs/b.log":    "b",
+		"logs/old/c.gz": "c",
+	})()
+
+	buckets, err := S3Buckets()
+	if err != nil || len(buckets) != 1 || *buckets[0].Name != "bucket" {
+		t.Errorf("Expected [bucket], but got %v %v", buckets, err)
+		return
+	}
+	actual, err := S3Objects("bucket", "logs/", "/", "", 0)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if len(actual.Objects) != 2 || len(actual.Prefixes) != 1 || actual.Prefixes[0] != "logs/old/" || actual.NextToken != "" {
+		t.Errorf("Expected 2 objects and logs/old/, but got %v", actual)
+		return
+	}
+	actual, err = S3Objects("bucket", "", "", "", 2)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if len(actual.Objects) != 2 || actual.NextToken != "logs/a.log" {
+		t.Errorf("Expected a truncated page, but got %v", actual)
+		return
+	}
+	actual, _ = S3Objects("bucket", "", "", actual.NextToken, 2)
+	if len(actual.Objects) != 2 || *actual.Objects[1].Key != "logs/old/c.gz" {
+		t.Errorf("Expected the rest of objects, but got %v", actual)
+	}
+	if region := s3Regions["bucket"]; region != "ap-northeast-1" {
+		t.Errorf("Expected %v, but got %v", "ap-northeast-1", region)
+	}
+}
+
+func TestS3Object(t *testing.T) {
+	defer fakeS3("bucket", map[string]string{"logs/a.log": "hello"})()
+
+	actual, err := S3Object("bucket", "logs/a.log")
+	if err != nil || actual == nil {
+		t.Errorf("Expected metadata, but got %v %v", actual, err)
+		return
+	}
+	if *actual.ContentLength != 5 {
+		t.Errorf("Expected %v, but got %v", 5, *actual.ContentLength)
+	}
+	actual, err = S3Object("bucket", "nothing")
+	if err != nil || actual != nil {
+		t.Errorf("Expected nothing, but got %v %v", actual, err)
+	}
+}
+
+func TestS3PresignedURL(t *testing.T) {
+	defer fakeS3("bucket", map[string]string{})()
+
+	actual, err := S3PresignedURL("bucket", "logs/a.log", "PUT", 15*time.Minute)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	for _, expected := range []string{"/bucket/logs/a.log?", "X-Amz-Expires=900", "X-Amz-Signature="} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("Expected %v to contain %v", actual, expected)
+		}
+	}
+}
+
+func TestS3Public(t *testing.T) {
+	security := &S3BucketSecurity{PublicACL: []string{"AllUsers READ"}}
+	if !s3Public(security) {
+		t.Errorf("Expected a public ACL to make the bucket public")
+	}
+	security.PublicAccessBlock = &s3.PublicAccessBlockConfiguration{IgnorePublicAcls: awssdk.Bool(true)}
+	if s3Public(security) {
+		t.Errorf("Expected the public access block to override the ACL")
+	}
+}
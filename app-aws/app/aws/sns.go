@@ -0,0 +1,77 @@
+package aws
+
+/**
+ * @see https://github.com/aws/aws-sdk-go/blob/master/service/sns/api.go
+ */
+import (
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	appcfg "github.com/pottava/golang-microservices/app-aws/app/config"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+)
+
+var snsCfg *awssdk.Config
+
+func init() {
+	snsCfg = config()
+	if endpoint := appcfg.NewConfig().AwsSnsEndpoint; endpoint != "" {
+		snsCfg.Endpoint = awssdk.String(endpoint)
+	}
+}
+
+func snsClient() *sns.SNS {
+	return sns.New(newSession(), snsCfg)
+}
+
+// SnsTopic represents a topic
+type SnsTopic struct {
+	Name string `json:"name"`
+	Arn  string `json:"arn"`
+}
+
+// SnsTopics returns topics
+func SnsTopics() (topics []*SnsTopic, e error) {
+	topics = []*SnsTopic{}
+	err := snsClient().ListTopicsPages(&sns.ListTopicsInput{}, func(res *sns.ListTopicsOutput, last bool) bool {
+		for _, topic := range res.Topics {
+			arn := awssdk.StringValue(topic.TopicArn)
+			topics = append(topics, &SnsTopic{Name: arn[strings.LastIndex(arn, ":")+1:], Arn: arn})
+		}
+		return true
+	})
+	if err != nil {
+		logs.Error.Print("Could not list SNS topics.")
+		return nil, err
+	}
+	return topics, nil
+}
+
+// SnsSubscriptions returns subscriptions of a topic
+func SnsSubscriptions(arn string) (subscriptions []*sns.Subscription, e error) {
+	subscriptions = []*sns.Subscription{}
+	err := snsClient().ListSubscriptionsByTopicPages(&sns.ListSubscriptionsByTopicInput{TopicArn: awssdk.String(arn)},
+		func(res *sns.ListSubscriptionsByTopicOutput, last bool) bool {
+			subscriptions = append(subscriptions, res.Subscriptions...)
+			return true
+		})
+	if err != nil {
+		logs.Error.Printf("Could not list subscriptions of %s.", arn)
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// SnsPublish publishes a message to a topic and returns its ID
+func SnsPublish(arn, subject, message string) (string, error) {
+	req := &sns.PublishInput{TopicArn: awssdk.String(arn), Message: awssdk.String(message)}
+	if subject != "" {
+		req.Subject = awssdk.String(subject)
+	}
+	res, err := snsClient().Publish(req)
+	if err != nil {
+		return "", err
+	}
+	return awssdk.StringValue(res.MessageId), nil
+}
@@ -0,0 +1,56 @@
+package aws
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestSnsTopics(t *testing.T) {
+	defer fakeQueryEndpoint(&snsCfg, func(action string, form url.Values) (int, string) {
+		if form.Get("NextToken") == "" {
+			return http.StatusOK, `<ListTopicsResponse><ListTopicsResult><Topics>` +
+				`<member><TopicArn>arn:aws:sns:us-east-1:123456789012:alerts</TopicArn></member>` +
+				`</Topics><NextToken>next</NextToken></ListTopicsResult></ListTopicsResponse>`
+		}
+		return http.StatusOK, `<ListTopicsResponse><ListTopicsResult><Topics>` +
+			`<member><TopicArn>arn:aws:sns:us-east-1:123456789012:deploys</TopicArn></member>` +
+			`</Topics></ListTopicsResult></ListTopicsResponse>`
+	})()
+
+	actual, err := SnsTopics()
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	expected := []string{"alerts", "deploys"}
+	if len(actual) != len(expected) {
+		t.Errorf("Expected %v topics, but got %v", len(expected), len(actual))
+		return
+	}
+	for idx, topic := range actual {
+		if topic.Name != expected[idx] {
+			t.Errorf("Expected %v, but got %v", expected[idx], topic.Name)
+		}
+	}
+}
+
+func TestSnsPublish(t *testing.T) {
+	var published url.Values
+	defer fakeQueryEndpoint(&snsCfg, func(action string, form url.Values) (int, string) {
+		published = form
+		return http.StatusOK, `<PublishResponse><PublishResult><MessageId>msg-1</MessageId></PublishResult></PublishResponse>`
+	})()
+
+	actual, err := SnsPublish("arn:aws:sns:us-east-1:123456789012:alerts", "", "hello")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if actual != "msg-1" {
+		t.Errorf("Expected %v, but got %v", "msg-1", actual)
+	}
+	if published.Get("Message") != "hello" || published.Get("Subject") != "" {
+		t.Errorf("Expected only a message to be published, but got %v", published)
+	}
+}
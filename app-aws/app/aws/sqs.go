@@ -0,0 +1,387 @@
+package aws
+
+/**
+ * @see https://github.com/aws/aws-sdk-go/blob/master/service/sqs/api.go
+ */
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	appcfg "github.com/pottava/golang-microservices/app-aws/app/config"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
+)
+
+var sqsCfg *awssdk.Config
+
+func init() {
+	sqsCfg = config()
+	if endpoint := appcfg.NewConfig().AwsSqsEndpoint; endpoint != "" {
+		sqsCfg.Endpoint = awssdk.String(endpoint)
+	}
+}
+
+func sqsClient() *sqs.SQS {
+	return sqs.New(newSession(), sqsCfg)
+}
+
+// SQS receives at most 10 messages at once
+const sqsMaxMessages = 10
+
+// sqsPeekVisibility is how long peeked messages stay hidden when they cannot be released
+const sqsPeekVisibility = 30
+
+// How long a redrive waits for messages with long polling, in seconds
+const sqsRedriveWait = 20
+
+// ErrNoSuchQueue means that the queue does not exist
+var ErrNoSuchQueue = errors.New("the queue does not exist")
+
+// ErrNoRedriveTarget means that the queue to move messages back to is unknown
+var ErrNoRedriveTarget = errors.New("no source queue uses this queue as its dead-letter queue, specify where to move messages")
+
+// ErrAmbiguousRedriveTarget means that more than one queue uses the dead-letter queue
+var ErrAmbiguousRedriveTarget = errors.New("more than one queue uses this queue as its dead-letter queue, specify where to move messages")
+
+// ErrRedriveToItself means that messages are asked to be moved to the queue they are in
+var ErrRedriveToItself = errors.New("messages can not be moved to the queue they are in")
+
+// ErrRedriveFifoMismatch means that messages are asked to be moved between a FIFO queue and a standard one
+var ErrRedriveFifoMismatch = errors.New("messages can be moved only between FIFO queues or between standard queues")
+
+// SqsQueue represents a queue with its approximate message counts
+type SqsQueue struct {
+	Name          string             `json:"name"`
+	URL           string             `json:"url"`
+	Arn           string             `json:"arn,omitempty"`
+	Messages      int                `json:"messages"`
+	InFlight      int                `json:"inFlight"`
+	Delayed       int                `json:"delayed"`
+	RedrivePolicy *SqsRedrivePolicy  `json:"redrivePolicy,omitempty"`
+	Attributes    map[string]*string `json:"attributes,omitempty"`
+}
+
+// SqsRedrivePolicy tells where messages which failed too many times go
+type SqsRedrivePolicy struct {
+	DeadLetterTargetArn string `json:"deadLetterTargetArn"`
+	MaxReceiveCount     int    `json:"maxReceiveCount"`
+}
+
+// SqsQueues returns queues whose names start with the prefix
+func SqsQueues(prefix string) (queues []*SqsQueue, e error) {
+	req := &sqs.ListQueuesInput{}
+	if prefix != "" {
+		req.QueueNamePrefix = awssdk.String(prefix)
+	}
+	queues = []*SqsQueue{}
+	err := sqsClient().ListQueuesPages(req, func(res *sqs.ListQueuesOutput, last bool) bool {
+		for _, url := range res.QueueUrls {
+			queues = append(queues, &SqsQueue{Name: sqsQueueName(*url), URL: *url})
+		}
+		return true
+	})
+	if err != nil {
+		logs.Error.Print("Could not list SQS queues.")
+		return nil, err
+	}
+	return queues, nil
+}
+
+// SqsQueueByName returns a queue with all of its attributes, or nil when it does not exist
+func SqsQueueByName(name string) (queue *SqsQueue, e error) {
+	url, err := sqsQueueURL(name)
+	if err == ErrNoSuchQueue {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	res, err := sqsClient().GetQueueAttributes(&sqs.GetQueueAttributesInput{
+		QueueUrl:       awssdk.String(url),
+		AttributeNames: []*string{awssdk.String(sqs.QueueAttributeNameAll)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	attributes := res.Attributes
+	queue = &SqsQueue{
+		Name:       name,
+		URL:        url,
+		Arn:        awssdk.StringValue(attributes[sqs.QueueAttributeNameQueueArn]),
+		Messages:   misc.Atoi(awssdk.StringValue(attributes[sqs.QueueAttributeNameApproximateNumberOfMessages])),
+		InFlight:   misc.Atoi(awssdk.StringValue(attributes[sqs.QueueAttributeNameApproximateNumberOfMessagesNotVisible])),
+		Delayed:    misc.Atoi(awssdk.StringValue(attributes[sqs.QueueAttributeNameApproximateNumberOfMessagesDelayed])),
+		Attributes: attributes,
+	}
+	if policy := awssdk.StringValue(attributes[sqs.QueueAttributeNameRedrivePolicy]); policy != "" {
+		queue.RedrivePolicy = sqsRedrivePolicy(policy)
+	}
+	return queue, nil
+}
+
+// SqsPeeked is what a peek returns. Released is false when the messages
+// could not be made visible again, so consumers get them only after the
+// visibility timeout of a peek
+type SqsPeeked struct {
+	Messages []*sqs.Message `json:"messages"`
+	Released bool           `json:"released"`
+}
+
+// SqsPeek receives messages without deleting them, hiding them for
+// sqsPeekVisibility seconds, then makes them visible to consumers again.
+// It is not read-only: receive counts go up, which can move the messages
+// to the dead-letter queue.
+func SqsPeek(name string, max int) (peeked *SqsPeeked, e error) {
+	url, err := sqsQueueURL(name)
+	if err != nil {
+		return nil, err
+	}
+	if max <= 0 || max > sqsMaxMessages {
+		max = sqsMaxMessages
+	}
+	res, err := sqsClient().ReceiveMessage(&sqs.ReceiveMessageInput{
+		QueueUrl:              awssdk.String(url),
+		MaxNumberOfMessages:   awssdk.Int64(int64(max)),
+		VisibilityTimeout:     awssdk.Int64(sqsPeekVisibility),
+		AttributeNames:        []*string{awssdk.String(sqs.QueueAttributeNameAll)},
+		MessageAttributeNames: []*string{awssdk.String(sqs.QueueAttributeNameAll)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	peeked = &SqsPeeked{Messages: res.Messages, Released: true}
+	if peeked.Messages == nil {
+		peeked.Messages = []*sqs.Message{}
+	}
+	if err = sqsRelease(url, res.Messages); err != nil {
+		logs.Error.Printf("Could not make peeked messages of %s visible again. Error: %v", name, err)
+		peeked.Released = false
+	}
+	return peeked, nil
+}
+
+// SqsPurge deletes all the messages in a queue
+func SqsPurge(name string) error {
+	url, err := sqsQueueURL(name)
+	if err != nil {
+		return err
+	}
+	_, err = sqsClient().PurgeQueue(&sqs.PurgeQueueInput{QueueUrl: awssdk.String(url)})
+	return err
+}
+
+// SqsRedriveTarget returns the queue which uses the dead-letter queue,
+// when there is only one of them
+func SqsRedriveTarget(dlq string) (string, error) {
+	url, err := sqsQueueURL(dlq)
+	if err != nil {
+		return "", err
+	}
+	res, err := sqsClient().ListDeadLetterSourceQueues(&sqs.ListDeadLetterSourceQueuesInput{QueueUrl: awssdk.String(url)})
+	if err != nil {
+		return "", err
+	}
+	switch len(res.QueueUrls) {
+	case 0:
+		return "", ErrNoRedriveTarget
+	case 1:
+		return sqsQueueName(*res.QueueUrls[0]), nil
+	}
+	return "", ErrAmbiguousRedriveTarget
+}
+
+// SqsRedrive moves messages from a dead-letter queue back to another queue
+type SqsRedrive struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Moved   int    `json:"moved"`
+	fromURL string
+	toURL   string
+	fifo    bool
+}
+
+// NewSqsRedrive confirms that both of the queues exist and can be redriven between
+func NewSqsRedrive(from, to string) (*SqsRedrive, error) {
+	if from == to {
+		return nil, ErrRedriveToItself
+	}
+	if strings.HasSuffix(from, ".fifo") != strings.HasSuffix(to, ".fifo") {
+		return nil, ErrRedriveFifoMismatch
+	}
+	redrive := &SqsRedrive{From: from, To: to, fifo: strings.HasSuffix(to, ".fifo")}
+	var err error
+	if redrive.fromURL, err = sqsQueueURL(from); err != nil {
+		return nil, err
+	}
+	if redrive.toURL, err = sqsQueueURL(to); err != nil {
+		return nil, err
+	}
+	return redrive, nil
+}
+
+// Run moves messages in batches until the dead-letter queue has no visible
+// messages, or the context is canceled
+func (redrive *SqsRedrive) Run(ctx context.Context, progress func(done, total int)) error {
+	total, err := redrive.remaining()
+	if err != nil {
+		return err
+	}
+	for {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		moved, err := redrive.batch(ctx)
+		redrive.Moved += moved
+		if err != nil {
+			return err
+		}
+		if moved == 0 {
+			// an empty receive does not mean that the queue is empty,
+			// as it reads only some of the servers even with long polling
+			remaining, err := redrive.remaining()
+			if err != nil || remaining == 0 {
+				return err
+			}
+		}
+		if total < redrive.Moved {
+			total = redrive.Moved
+		}
+		progress(redrive.Moved, total)
+	}
+}
+
+// batch moves at most 10 messages, and deletes only the ones which were sent
+func (redrive *SqsRedrive) batch(ctx context.Context) (int, error) {
+	client := sqsClient()
+	res, err := client.ReceiveMessageWithContext(ctx, &sqs.ReceiveMessageInput{
+		QueueUrl:              awssdk.String(redrive.fromURL),
+		MaxNumberOfMessages:   awssdk.Int64(sqsMaxMessages),
+		VisibilityTimeout:     awssdk.Int64(60),
+		WaitTimeSeconds:       awssdk.Int64(sqsRedriveWait),
+		AttributeNames:        []*string{awssdk.String(sqs.MessageSystemAttributeNameMessageGroupId), awssdk.String(sqs.MessageSystemAttributeNameMessageDeduplicationId)},
+		MessageAttributeNames: []*string{awssdk.String(sqs.QueueAttributeNameAll)},
+	})
+	if err != nil || len(res.Messages) == 0 {
+		return 0, err
+	}
+	entries := []*sqs.SendMessageBatchRequestEntry{}
+	for idx, message := range res.Messages {
+		entry := &sqs.SendMessageBatchRequestEntry{
+			Id:                awssdk.String(strconv.Itoa(idx)),
+			MessageBody:       message.Body,
+			MessageAttributes: message.MessageAttributes,
+		}
+		if redrive.fifo {
+			entry.MessageGroupId = message.Attributes[sqs.MessageSystemAttributeNameMessageGroupId]
+			entry.MessageDeduplicationId = message.Attributes[sqs.MessageSystemAttributeNameMessageDeduplicationId]
+		}
+		entries = append(entries, entry)
+	}
+	sent, err := client.SendMessageBatchWithContext(ctx, &sqs.SendMessageBatchInput{
+		QueueUrl: awssdk.String(redrive.toURL),
+		Entries:  entries,
+	})
+	if err != nil {
+		return 0, err
+	}
+	deletes := []*sqs.DeleteMessageBatchRequestEntry{}
+	for _, entry := range sent.Successful {
+		deletes = append(deletes, &sqs.DeleteMessageBatchRequestEntry{
+			Id:            entry.Id,
+			ReceiptHandle: res.Messages[misc.Atoi(awssdk.StringValue(entry.Id))].ReceiptHandle,
+		})
+	}
+	moved := 0
+	if len(deletes) > 0 {
+		deleted, err := client.DeleteMessageBatchWithContext(ctx, &sqs.DeleteMessageBatchInput{
+			QueueUrl: awssdk.String(redrive.fromURL),
+			Entries:  deletes,
+		})
+		if err != nil {
+			return 0, err
+		}
+		moved = len(deleted.Successful)
+		if len(deleted.Failed) > 0 {
+			return moved, fmt.Errorf("could not delete a moved message: %s", awssdk.StringValue(deleted.Failed[0].Message))
+		}
+	}
+	if len(sent.Failed) > 0 {
+		return moved, fmt.Errorf("could not move a message: %s", awssdk.StringValue(sent.Failed[0].Message))
+	}
+	return moved, nil
+}
+
+// remaining returns the approximate number of visible messages in the dead-letter queue
+func (redrive *SqsRedrive) remaining() (int, error) {
+	res, err := sqsClient().GetQueueAttributes(&sqs.GetQueueAttributesInput{
+		QueueUrl:       awssdk.String(redrive.fromURL),
+		AttributeNames: []*string{awssdk.String(sqs.QueueAttributeNameApproximateNumberOfMessages)},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return misc.Atoi(awssdk.StringValue(res.Attributes[sqs.QueueAttributeNameApproximateNumberOfMessages])), nil
+}
+
+// sqsQueueURL finds the url of a queue
+func sqsQueueURL(name string) (string, error) {
+	res, err := sqsClient().GetQueueUrl(&sqs.GetQueueUrlInput{QueueName: awssdk.String(name)})
+	if aerr, ok := err.(awserr.Error); ok && aerr.Code() == sqs.ErrCodeQueueDoesNotExist {
+		return "", ErrNoSuchQueue
+	}
+	if err != nil {
+		return "", err
+	}
+	return awssdk.StringValue(res.QueueUrl), nil
+}
+
+// sqsRelease makes received messages visible again
+func sqsRelease(url string, messages []*sqs.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	entries := []*sqs.ChangeMessageVisibilityBatchRequestEntry{}
+	for _, message := range messages {
+		entries = append(entries, &sqs.ChangeMessageVisibilityBatchRequestEntry{
+			Id:                message.MessageId,
+			ReceiptHandle:     message.ReceiptHandle,
+			VisibilityTimeout: awssdk.Int64(0),
+		})
+	}
+	res, err := sqsClient().ChangeMessageVisibilityBatch(&sqs.ChangeMessageVisibilityBatchInput{
+		QueueUrl: awssdk.String(url),
+		Entries:  entries,
+	})
+	if err != nil {
+		return err
+	}
+	if len(res.Failed) > 0 {
+		return fmt.Errorf("could not release a message: %s", awssdk.StringValue(res.Failed[0].Message))
+	}
+	return nil
+}
+
+// sqsRedrivePolicy parses a redrive policy, whose count can be a string or a number
+func sqsRedrivePolicy(policy string) *SqsRedrivePolicy {
+	raw := struct {
+		DeadLetterTargetArn string      `json:"deadLetterTargetArn"`
+		MaxReceiveCount     json.Number `json:"maxReceiveCount"`
+	}{}
+	if err := json.Unmarshal([]byte(policy), &raw); err != nil {
+		logs.Warn.Printf("Could not parse a redrive policy %s. Error: %v", policy, err)
+		return nil
+	}
+	count, _ := raw.MaxReceiveCount.Int64()
+	return &SqsRedrivePolicy{DeadLetterTargetArn: raw.DeadLetterTargetArn, MaxReceiveCount: int(count)}
+}
+
+func sqsQueueName(url string) string {
+	return url[strings.LastIndex(url, "/")+1:]
+}
@@ -0,0 +1,395 @@
+package aws
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+)
+
+// sqsStandIn is an in-memory SQS which keeps messages of its queues
+// and hides received ones until they are made visible again
+type sqsStandIn struct {
+	mutex    sync.Mutex
+	url      string
+	queues   map[string][]*sqsStandInMessage
+	policies map[string]string
+	seq      int
+	// empties is how many receives answer nothing even when there are
+	// messages, as SQS does when it reads only some of its servers
+	empties int
+	// stuck makes received messages fail to be made visible again
+	stuck bool
+}
+
+type sqsStandInMessage struct {
+	id      string
+	body    string
+	group   string
+	handle  string
+	hidden  bool
+	receive int
+}
+
+// fakeSqs points the sqs client to a local queue stand-in,
+// and returns it along with a function to restore the client
+func fakeSqs(queues ...string) (*sqsStandIn, func()) {
+	standIn := &sqsStandIn{queues: map[string][]*sqsStandInMessage{}, policies: map[string]string{}}
+	for _, name := range queues {
+		standIn.queues[name] = []*sqsStandInMessage{}
+	}
+	server := httptest.NewServer(standIn)
+	standIn.url = server.URL
+
+	original := sqsCfg
+	sqsCfg = &awssdk.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    awssdk.String(server.URL),
+		Region:      awssdk.String("us-east-1"),
+		MaxRetries:  awssdk.Int(0),
+	}
+	return standIn, func() {
+		sqsCfg = original
+		server.Close()
+	}
+}
+
+func (q *sqsStandIn) send(name string, bodies ...string) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for _, body := range bodies {
+		q.seq++
+		q.queues[name] = append(q.queues[name], &sqsStandInMessage{id: fmt.Sprintf("msg-%d", q.seq), body: body})
+	}
+}
+
+func (q *sqsStandIn) bodies(name string) []string {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	bodies := []string{}
+	for _, message := range q.queues[name] {
+		bodies = append(bodies, message.body)
+	}
+	return bodies
+}
+
+func (q *sqsStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req := map[string]interface{}{}
+	json.NewDecoder(r.Body).Decode(&req)
+	action := r.Header.Get("X-Amz-Target")
+	action = action[strings.LastIndex(action, ".")+1:]
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	name, _ := req["QueueName"].(string)
+	if url, ok := req["QueueUrl"].(string); ok {
+		name = sqsQueueName(url)
+	}
+	messages, found := q.queues[name]
+	if !found && action != "ListQueues" {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.Header().Set("x-amzn-query-error", "AWS.SimpleQueueService.NonExistentQueue;Sender")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"__type": "com.amazonaws.sqs#QueueDoesNotExist", "message": "The specified queue does not exist."}`))
+		return
+	}
+	res := map[string]interface{}{}
+	switch action {
+	case "ListQueues":
+		urls := []string{}
+		for queue := range q.queues {
+			if prefix, _ := req["QueueNamePrefix"].(string); strings.HasPrefix(queue, prefix) {
+				urls = append(urls, q.url+"/123456789012/"+queue)
+			}
+		}
+		res["QueueUrls"] = urls
+	case "GetQueueUrl":
+		res["QueueUrl"] = q.url + "/123456789012/" + name
+	case "GetQueueAttributes":
+		visible, hidden := 0, 0
+		for _, message := range messages {
+			if message.hidden {
+				hidden++
+			} else {
+				visible++
+			}
+		}
+		attributes := map[string]string{
+			"QueueArn":                              "arn:aws:sqs:us-east-1:123456789012:" + name,
+			"ApproximateNumberOfMessages":           fmt.Sprint(visible),
+			"ApproximateNumberOfMessagesNotVisible": fmt.Sprint(hidden),
+			"ApproximateNumberOfMessagesDelayed":    "0",
+		}
+		if policy, found := q.policies[name]; found {
+			attributes["RedrivePolicy"] = policy
+		}
+		res["Attributes"] = attributes
+	case "ListDeadLetterSourceQueues":
+		urls := []string{}
+		for queue, policy := range q.policies {
+			if strings.HasSuffix(sqsRedrivePolicy(policy).DeadLetterTargetArn, ":"+name) {
+				urls = append(urls, q.url+"/123456789012/"+queue)
+			}
+		}
+		res["queueUrls"] = urls
+	case "ReceiveMessage":
+		if q.empties > 0 {
+			q.empties--
+			res["Messages"] = []interface{}{}
+			break
+		}
+		max := 1
+		if n, ok := req["MaxNumberOfMessages"].(float64); ok {
+			max = int(n)
+		}
+		received := []map[string]interface{}{}
+		for _, message := range messages {
+			if len(received) == max {
+				break
+			}
+			if message.hidden {
+				continue
+			}
+			q.seq++
+			message.hidden = true
+			message.receive++
+			message.handle = fmt.Sprintf("handle-%d", q.seq)
+			received = append(received, map[string]interface{}{
+				"MessageId": message.id, "ReceiptHandle": message.handle, "Body": message.body,
+				"MD5OfBody":  fmt.Sprintf("%x", md5.Sum([]byte(message.body))),
+				"Attributes": map[string]string{"MessageGroupId": message.group},
+			})
+		}
+		res["Messages"] = received
+	case "ChangeMessageVisibilityBatch":
+		failed := []map[string]interface{}{}
+		for _, entry := range req["Entries"].([]interface{}) {
+			handle := entry.(map[string]interface{})["ReceiptHandle"]
+			if q.stuck {
+				failed = append(failed, map[string]interface{}{
+					"Id": entry.(map[string]interface{})["Id"], "Code": "ReceiptHandleIsInvalid",
+					"Message": "The receipt handle has expired.", "SenderFault": true,
+				})
+				continue
+			}
+			for _, message := range messages {
+				if message.handle == handle {
+					message.hidden = false
+				}
+			}
+		}
+		res["Failed"] = failed
+	case "SendMessage":
+		q.seq++
+		q.queues[name] = append(messages, &sqsStandInMessage{id: fmt.Sprintf("msg-%d", q.seq), body: req["MessageBody"].(string)})
+		res["MessageId"] = fmt.Sprintf("msg-%d", q.seq)
+		res["MD5OfMessageBody"] = fmt.Sprintf("%x", md5.Sum([]byte(req["MessageBody"].(string))))
+	case "SendMessageBatch":
+		successful := []map[string]string{}
+		for _, entry := range req["Entries"].([]interface{}) {
+			entry := entry.(map[string]interface{})
+			if strings.HasSuffix(name, ".fifo") && entry["MessageGroupId"] == nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"__type": "com.amazonaws.sqs#MissingParameter", "message": "MessageGroupId is required."}`))
+				return
+			}
+			q.seq++
+			body := entry["MessageBody"].(string)
+			group, _ := entry["MessageGroupId"].(string)
+			q.queues[name] = append(q.queues[name], &sqsStandInMessage{id: fmt.Sprintf("msg-%d", q.seq), body: body, group: group})
+			successful = append(successful, map[string]string{
+				"Id": entry["Id"].(string), "MessageId": fmt.Sprintf("msg-%d", q.seq),
+				"MD5OfMessageBody": fmt.Sprintf("%x", md5.Sum([]byte(body))),
+			})
+		}
+		res["Successful"] = successful
+		res["Failed"] = []interface{}{}
+	case "DeleteMessageBatch":
+		successful := []map[string]string{}
+		for _, entry := range req["Entries"].([]interface{}) {
+			entry := entry.(map[string]interface{})
+			for idx, message := range q.queues[name] {
+				if message.handle == entry["ReceiptHandle"] {
+					q.queues[name] = append(q.queues[name][:idx], q.queues[name][idx+1:]...)
+					successful = append(successful, map[string]string{"Id": entry["Id"].(string)})
+					break
+				}
+			}
+		}
+		res["Successful"] = successful
+		res["Failed"] = []interface{}{}
+	case "DeleteMessage":
+		for idx, message := range messages {
+			if message.handle == req["ReceiptHandle"] {
+				q.queues[name] = append(messages[:idx], messages[idx+1:]...)
+				break
+			}
+		}
+	case "PurgeQueue":
+		q.queues[name] = []*sqsStandInMessage{}
+	}
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	json.NewEncoder(w).Encode(res)
+}
+
+func TestSqsQueueByName(t *testing.T) {
+	standIn, restore := fakeSqs("orders", "orders-dlq")
+	defer restore()
+	standIn.policies["orders"] = `{"deadLetterTargetArn":"arn:aws:sqs:us-east-1:123456789012:orders-dlq","maxReceiveCount":"5"}`
+	standIn.send("orders", "a", "b")
+
+	actual, err := SqsQueueByName("orders")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if actual.Messages != 2 || actual.InFlight != 0 {
+		t.Errorf("Expected %v messages, but got %v and %v in flight", 2, actual.Messages, actual.InFlight)
+	}
+	if actual.RedrivePolicy == nil || actual.RedrivePolicy.MaxReceiveCount != 5 {
+		t.Errorf("Expected %v, but got %v", 5, actual.RedrivePolicy)
+	}
+	missing, err := SqsQueueByName("missing")
+	if err != nil || missing != nil {
+		t.Errorf("Expected %v, but got %v, %v", nil, missing, err)
+	}
+}
+
+func TestSqsPeek(t *testing.T) {
+	standIn, restore := fakeSqs("orders")
+	defer restore()
+	standIn.send("orders", "a", "b", "c")
+
+	actual, err := SqsPeek("orders", 2)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if len(actual.Messages) != 2 || *actual.Messages[0].Body != "a" || !actual.Released {
+		t.Errorf("Expected %v released messages, but got %v", 2, actual)
+	}
+	queue, _ := SqsQueueByName("orders")
+	if queue.Messages != 3 || queue.InFlight != 0 {
+		t.Errorf("Expected peeked messages to stay visible, but got %v visible and %v in flight", queue.Messages, queue.InFlight)
+	}
+	if _, err = SqsPeek("missing", 2); err != ErrNoSuchQueue {
+		t.Errorf("Expected %v, but got %v", ErrNoSuchQueue, err)
+	}
+}
+
+func TestSqsPeekNotReleased(t *testing.T) {
+	standIn, restore := fakeSqs("orders")
+	defer restore()
+	standIn.send("orders", "a", "b")
+	standIn.stuck = true
+
+	actual, err := SqsPeek("orders", 10)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if len(actual.Messages) != 2 || actual.Released {
+		t.Errorf("Expected the messages to be reported as not released, but got %v", actual)
+	}
+}
+
+func TestSqsRedrive(t *testing.T) {
+	standIn, restore := fakeSqs("orders", "orders-dlq", "payments")
+	defer restore()
+	standIn.policies["orders"] = `{"deadLetterTargetArn":"arn:aws:sqs:us-east-1:123456789012:orders-dlq","maxReceiveCount":5}`
+	standIn.send("orders", "new")
+	for i := 0; i < 12; i++ {
+		standIn.send("orders-dlq", fmt.Sprintf("failed-%d", i))
+	}
+	standIn.empties = 2
+
+	target, err := SqsRedriveTarget("orders-dlq")
+	if err != nil || target != "orders" {
+		t.Errorf("Expected %v, but got %v, %v", "orders", target, err)
+		return
+	}
+	if _, err = SqsRedriveTarget("payments"); err != ErrNoRedriveTarget {
+		t.Errorf("Expected %v, but got %v", ErrNoRedriveTarget, err)
+	}
+	redrive, err := NewSqsRedrive("orders-dlq", target)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	done, total := 0, 0
+	if err = redrive.Run(context.Background(), func(d, t int) { done, total = d, t }); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	// empty receives while messages remain do not stop the redrive
+	if redrive.Moved != 12 || done != 12 || total != 12 {
+		t.Errorf("Expected %v, but got %v moved and %v/%v done", 12, redrive.Moved, done, total)
+	}
+	if actual := standIn.bodies("orders-dlq"); len(actual) != 0 {
+		t.Errorf("Expected the dead-letter queue to be empty, but got %v", actual)
+	}
+	if actual := standIn.bodies("orders"); len(actual) != 13 || actual[0] != "new" || actual[12] != "failed-11" {
+		t.Errorf("Expected %v messages in order, but got %v", 13, actual)
+	}
+}
+
+func TestNewSqsRedrive(t *testing.T) {
+	_, restore := fakeSqs("orders", "orders-dlq", "jobs.fifo")
+	defer restore()
+
+	for to, expected := range map[string]error{
+		"orders-dlq": ErrRedriveToItself,
+		"jobs.fifo":  ErrRedriveFifoMismatch,
+		"missing":    ErrNoSuchQueue,
+	} {
+		if _, err := NewSqsRedrive("orders-dlq", to); err != expected {
+			t.Errorf("Expected %v for %v, but got %v", expected, to, err)
+		}
+	}
+}
+
+func TestSqsRedriveFifo(t *testing.T) {
+	standIn, restore := fakeSqs("jobs.fifo", "jobs-dlq.fifo")
+	defer restore()
+	standIn.send("jobs-dlq.fifo", "a", "b")
+	standIn.queues["jobs-dlq.fifo"][0].group = "g1"
+	standIn.queues["jobs-dlq.fifo"][1].group = "g2"
+
+	redrive, err := NewSqsRedrive("jobs-dlq.fifo", "jobs.fifo")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if err = redrive.Run(context.Background(), func(done, total int) {}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	messages := standIn.queues["jobs.fifo"]
+	if len(messages) != 2 || messages[0].group != "g1" || messages[1].group != "g2" {
+		t.Errorf("Expected messages to keep their groups, but got %v", messages)
+	}
+}
+
+func TestSqsRedrivePolicy(t *testing.T) {
+	for _, policy := range []string{
+		`{"deadLetterTargetArn":"arn:dlq","maxReceiveCount":"3"}`,
+		`{"deadLetterTargetArn":"arn:dlq","maxReceiveCount":3}`,
+	} {
+		actual := sqsRedrivePolicy(policy)
+		if actual == nil || actual.DeadLetterTargetArn != "arn:dlq" || actual.MaxReceiveCount != 3 {
+			t.Errorf("Expected %v, but got %v", 3, actual)
+		}
+	}
+	if actual := sqsRedrivePolicy("broken"); actual != nil {
+		t.Errorf("Expected %v, but got %v", nil, actual)
+	}
+}
@@ -0,0 +1,100 @@
+// Package cache keeps results of expensive calls for a while, and lets
+// concurrent callers of the same key share one call
+package cache
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	value   interface{}
+	created time.Time
+}
+
+// call is an in-flight call which other callers of the same key wait for
+type call struct {
+	wg    sync.WaitGroup
+	value interface{}
+	err   error
+	gen   int
+}
+
+// Cache stores values by keys for its TTL. Nothing is stored when
+// the TTL is zero or negative, but concurrent calls are still coalesced.
+type Cache struct {
+	TTL     time.Duration
+	mutex   sync.Mutex
+	entries map[string]*entry
+	calls   map[string]*call
+	gen     int
+	now     func() time.Time
+}
+
+// New returns an empty cache
+func New(ttl time.Duration) *Cache {
+	return &Cache{
+		TTL:     ttl,
+		entries: map[string]*entry{},
+		calls:   map[string]*call{},
+		now:     time.Now,
+	}
+}
+
+// Do returns the value of the key and how old it is. The function is called
+// only when the value is not cached yet, and only once for concurrent callers.
+// Errors are not cached.
+func (cache *Cache) Do(key string, fn func() (interface{}, error)) (value interface{}, age time.Duration, err error) {
+	cache.mutex.Lock()
+	now := cache.now()
+	if e, found := cache.entries[key]; found {
+		if age = now.Sub(e.created); age < cache.TTL {
+			cache.mutex.Unlock()
+			return e.value, age, nil
+		}
+		delete(cache.entries, key)
+	}
+	if c, found := cache.calls[key]; found {
+		cache.mutex.Unlock()
+		c.wg.Wait()
+		return c.value, 0, c.err
+	}
+	c := &call{gen: cache.gen}
+	c.wg.Add(1)
+	cache.calls[key] = c
+	cache.mutex.Unlock()
+
+	c.value, c.err = fn()
+	c.wg.Done()
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	delete(cache.calls, key)
+	// values fetched before an invalidation may be stale already
+	if c.err == nil && cache.TTL > 0 && c.gen == cache.gen {
+		cache.entries[key] = &entry{value: c.value, created: now}
+	}
+	return c.value, 0, c.err
+}
+
+// Invalidate forgets values whose keys start with any of the prefixes,
+// or everything without prefixes
+func (cache *Cache) Invalidate(prefixes ...string) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.gen++
+	for key := range cache.entries {
+		if len(prefixes) == 0 {
+			delete(cache.entries, key)
+			continue
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(key, prefix) {
+				delete(cache.entries, key)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	now := time.Date(2016, 1, 2, 0, 0, 0, 0, time.UTC)
+	cache := New(30 * time.Second)
+	cache.now = func() time.Time { return now }
+
+	calls := 0
+	fn := func() (interface{}, error) {
+		calls++
+		return calls, nil
+	}
+	value, age, _ := cache.Do("key", fn)
+	if value != 1 || age != 0 {
+		t.Errorf("Expected a fresh value, but got %v aged %v", value, age)
+	}
+	now = now.Add(10 * time.Second)
+	value, age, _ = cache.Do("key", fn)
+	if value != 1 || age != 10*time.Second {
+		t.Errorf("Expected a cached value aged 10s, but got %v aged %v", value, age)
+	}
+	now = now.Add(20 * time.Second)
+	if value, _, _ = cache.Do("key", fn); value != 2 {
+		t.Errorf("Expected an expired value to be fetched again, but got %v", value)
+	}
+	if _, _, err := cache.Do("error", func() (interface{}, error) { return nil, errors.New("failed") }); err == nil {
+		t.Errorf("Expected an error")
+	}
+	if value, _, err := cache.Do("error", fn); err != nil || value != 3 {
+		t.Errorf("Expected errors not to be cached, but got %v %v", value, err)
+	}
+}
+
+func TestDoCoalesces(t *testing.T) {
+	cache := New(0)
+	release := make(chan bool)
+	calls := 0
+
+	var wg sync.WaitGroup
+	values := make([]interface{}, 5)
+	for i := range values {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			values[i], _, _ = cache.Do("key", func() (interface{}, error) {
+				calls++
+				<-release
+				return "value", nil
+			})
+		}(i)
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected %v call, but got %v", 1, calls)
+	}
+	for _, value := range values {
+		if value != "value" {
+			t.Errorf("Expected %v, but got %v", "value", value)
+		}
+	}
+	if len(cache.entries) != 0 {
+		t.Errorf("Expected nothing to be cached without a TTL, but got %v", cache.entries)
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	cache := New(time.Minute)
+	fn := func() (interface{}, error) { return "value", nil }
+	cache.Do("ec2:a", fn)
+	cache.Do("ec2:b", fn)
+	cache.Do("s3:a", fn)
+
+	cache.Invalidate("ec2:")
+	if len(cache.entries) != 1 || cache.entries["s3:a"] == nil {
+		t.Errorf("Expected only s3:a to remain, but got %v", cache.entries)
+	}
+
+	// values fetched across an invalidation are not kept
+	cache.Do("ec2:c", func() (interface{}, error) {
+		cache.Invalidate("ec2:")
+		return "stale", nil
+	})
+	if _, found := cache.entries["ec2:c"]; found {
+		t.Errorf("Expected a stale value not to be cached")
+	}
+	cache.Invalidate()
+	if len(cache.entries) != 0 {
+		t.Errorf("Expected everything to be forgotten, but got %v", cache.entries)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"log"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,12 +17,32 @@ import (
 
 func defaultConfig() Config {
 	return Config{
-		Name:          "GoMicroservices-AWS",
-		Port:          80,
-		LogLevel:      4,
-		AccessLog:     true,
-		AwsLog:        false,
-		AwsRoleExpiry: 5 * time.Minute,
+		Name:             "GoMicroservices-AWS",
+		Port:             80,
+		LogLevel:         4,
+		AccessLog:        true,
+		AwsLog:           false,
+		AwsRoleExpiry:    5 * time.Minute,
+		AwsEc2Endpoint:   "",
+		AwsS3Endpoint:    "",
+		AwsSqsEndpoint:   "",
+		AwsSnsEndpoint:   "",
+		AwsRegions:       []string{},
+		AwsAssumeRoles:   []string{},
+		AwsRegionWorkers: 8,
+		AwsMaxRetries:    intOf(5),
+		AwsRetryBase:     100 * time.Millisecond,
+		AwsRetryMax:      20 * time.Second,
+		AwsRateLimit:     10,
+		AwsRateBurst:     20,
+		ScheduleEvery:    0,
+		ScheduleStore:    "/var/lib/golang-microservices/schedules.json",
+		PricingFile:      "/etc/golang-microservices/pricing.json",
+		RdsStoppable:     []string{},
+		IamKeyMaxAge:     90,
+		JobWorkers:       4,
+		CacheTTL:         durationOf(30 * time.Second),
+		LaunchProfiles:   []LaunchProfile{},
 	}
 }
 
@@ -43,12 +64,32 @@ func NewConfig() *Config {
 
 func environmentConfig() Config {
 	return Config{
-		Name:          os.Getenv("APP_NAME"),
-		Port:          misc.ParseUint16(os.Getenv("APP_PORT")),
-		LogLevel:      misc.Atoi(os.Getenv("APP_LOG_LEVEL")),
-		AccessLog:     misc.ParseBool(os.Getenv("APP_ACCESS_LOG")),
-		AwsLog:        misc.ParseBool(os.Getenv("APP_AWS_LOG")),
-		AwsRoleExpiry: misc.ParseDuration(os.Getenv("APP_AWS_ROLE_EXPIRY")),
+		Name:             os.Getenv("APP_NAME"),
+		Port:             misc.ParseUint16(os.Getenv("APP_PORT")),
+		LogLevel:         misc.Atoi(os.Getenv("APP_LOG_LEVEL")),
+		AccessLog:        misc.ParseBool(os.Getenv("APP_ACCESS_LOG")),
+		AwsLog:           misc.ParseBool(os.Getenv("APP_AWS_LOG")),
+		AwsRoleExpiry:    misc.ParseDuration(os.Getenv("APP_AWS_ROLE_EXPIRY")),
+		AwsEc2Endpoint:   os.Getenv("APP_AWS_EC2_ENDPOINT"),
+		AwsS3Endpoint:    os.Getenv("APP_AWS_S3_ENDPOINT"),
+		AwsSqsEndpoint:   os.Getenv("APP_AWS_SQS_ENDPOINT"),
+		AwsSnsEndpoint:   os.Getenv("APP_AWS_SNS_ENDPOINT"),
+		AwsRegions:       toStringArray(os.Getenv("APP_AWS_REGIONS")),
+		AwsAssumeRoles:   toStringArray(os.Getenv("APP_AWS_ASSUME_ROLES")),
+		AwsRegionWorkers: misc.Atoi(os.Getenv("APP_AWS_REGION_WORKERS")),
+		AwsMaxRetries:    parseInt(os.Getenv("APP_AWS_MAX_RETRIES")),
+		AwsRetryBase:     misc.ParseDuration(os.Getenv("APP_AWS_RETRY_BASE")),
+		AwsRetryMax:      misc.ParseDuration(os.Getenv("APP_AWS_RETRY_MAX")),
+		AwsRateLimit:     misc.Atoi(os.Getenv("APP_AWS_RATE_LIMIT")),
+		AwsRateBurst:     misc.Atoi(os.Getenv("APP_AWS_RATE_BURST")),
+		ScheduleEvery:    misc.ParseDuration(os.Getenv("APP_SCHEDULE_EVERY")),
+		ScheduleStore:    os.Getenv("APP_SCHEDULE_STORE"),
+		PricingFile:      os.Getenv("APP_PRICING_FILE"),
+		RdsStoppable:     toStringArray(os.Getenv("APP_RDS_STOPPABLE")),
+		IamKeyMaxAge:     misc.Atoi(os.Getenv("APP_IAM_KEY_MAX_AGE")),
+		JobWorkers:       misc.Atoi(os.Getenv("APP_JOB_WORKERS")),
+		CacheTTL:         parseDuration(os.Getenv("APP_CACHE_TTL")),
+		LaunchProfiles:   []LaunchProfile{},
 	}
 }
 
@@ -59,6 +100,30 @@ func toStringArray(values string) []string {
 	return strings.Split(values, ",")
 }
 
+// parseInt and parseDuration return nil for an empty or invalid value,
+// so that it can be told from zero when configs are merged
+func parseInt(candidate string) *int {
+	if i, err := strconv.Atoi(candidate); err == nil {
+		return &i
+	}
+	return nil
+}
+
+func parseDuration(candidate string) *time.Duration {
+	if d, err := time.ParseDuration(candidate); err == nil {
+		return &d
+	}
+	return nil
+}
+
+func intOf(i int) *int {
+	return &i
+}
+
+func durationOf(d time.Duration) *time.Duration {
+	return &d
+}
+
 func fileConfig() Config {
 	path := misc.NVL(os.Getenv("CONFIG_FILE_PATH"), "/etc/golang-microservices/config.json")
 	file, err := os.Open(path)
@@ -130,7 +195,15 @@ func (config *Config) trimWhitespace() {
 func (config *Config) String() string {
 	return fmt.Sprintf(
 		"Name: %v, Port: %v, LogLevel: %v, AccessLog: %v, "+
-			"AwsRegion: %v, AwsLog: %v, AwsRoleExpiry: %v",
+			"AwsRegion: %v, AwsLog: %v, AwsRoleExpiry: %v, AwsEc2Endpoint: %v, AwsS3Endpoint: %v, "+
+			"AwsSqsEndpoint: %v, AwsSnsEndpoint: %v, "+
+			"AwsRegions: %v, AwsAssumeRoles: %v, AwsRegionWorkers: %v, AwsMaxRetries: %v, AwsRetryBase: %v, AwsRetryMax: %v, "+
+			"AwsRateLimit: %v, AwsRateBurst: %v, ScheduleEvery: %v, ScheduleStore: %v, "+
+			"PricingFile: %v, RdsStoppable: %v, IamKeyMaxAge: %vd, JobWorkers: %v, CacheTTL: %v, LaunchProfiles: %v",
 		config.Name, config.Port, config.LogLevel, config.AccessLog,
-		os.Getenv("AWS_REGION"), config.AwsLog, config.AwsRoleExpiry)
+		os.Getenv("AWS_REGION"), config.AwsLog, config.AwsRoleExpiry, config.AwsEc2Endpoint, config.AwsS3Endpoint,
+		config.AwsSqsEndpoint, config.AwsSnsEndpoint,
+		config.AwsRegions, config.AwsAssumeRoles, config.AwsRegionWorkers, *config.AwsMaxRetries, config.AwsRetryBase, config.AwsRetryMax,
+		config.AwsRateLimit, config.AwsRateBurst, config.ScheduleEvery, config.ScheduleStore,
+		config.PricingFile, config.RdsStoppable, config.IamKeyMaxAge, config.JobWorkers, *config.CacheTTL, len(config.LaunchProfiles))
 }
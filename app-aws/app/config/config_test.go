@@ -0,0 +1,41 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCacheTTL(t *testing.T) {
+	original := os.Getenv("CONFIG_FILE_PATH")
+	os.Setenv("CONFIG_FILE_PATH", "/nonexistent/config.json")
+	defer os.Setenv("CONFIG_FILE_PATH", original)
+	defer os.Unsetenv("APP_CACHE_TTL")
+
+	for value, expected := range map[string]time.Duration{
+		"":    30 * time.Second,
+		"0":   0,
+		"0s":  0,
+		"5m":  5 * time.Minute,
+		"bad": 30 * time.Second,
+	} {
+		os.Setenv("APP_CACHE_TTL", value)
+		if actual := *NewConfig().CacheTTL; actual != expected {
+			t.Errorf("Expected %v for %q, but got %v", expected, value, actual)
+		}
+	}
+}
+
+func TestAwsMaxRetries(t *testing.T) {
+	original := os.Getenv("CONFIG_FILE_PATH")
+	os.Setenv("CONFIG_FILE_PATH", "/nonexistent/config.json")
+	defer os.Setenv("CONFIG_FILE_PATH", original)
+	defer os.Unsetenv("APP_AWS_MAX_RETRIES")
+
+	for value, expected := range map[string]int{"": 5, "0": 0, "3": 3} {
+		os.Setenv("APP_AWS_MAX_RETRIES", value)
+		if actual := *NewConfig().AwsMaxRetries; actual != expected {
+			t.Errorf("Expected %v for %q, but got %v", expected, value, actual)
+		}
+	}
+}
@@ -4,10 +4,42 @@ import "time"
 
 // Config defines the application configurations
 type Config struct {
-	Name          string `trim:"true"`
-	Port          uint16
-	LogLevel      int
-	AccessLog     bool
-	AwsLog        bool
-	AwsRoleExpiry time.Duration
+	Name             string `trim:"true"`
+	Port             uint16
+	LogLevel         int
+	AccessLog        bool
+	AwsLog           bool
+	AwsRoleExpiry    time.Duration
+	AwsEc2Endpoint   string `trim:"true"`
+	AwsS3Endpoint    string `trim:"true"`
+	AwsSqsEndpoint   string `trim:"true"`
+	AwsSnsEndpoint   string `trim:"true"`
+	AwsRegions       []string
+	AwsAssumeRoles   []string
+	AwsRegionWorkers int
+	AwsMaxRetries    *int // 0 disables retries
+	AwsRetryBase     time.Duration
+	AwsRetryMax      time.Duration
+	AwsRateLimit     int
+	AwsRateBurst     int
+	ScheduleEvery    time.Duration
+	ScheduleStore    string `trim:"true"`
+	PricingFile      string `trim:"true"`
+	RdsStoppable     []string
+	IamKeyMaxAge     int // days
+	JobWorkers       int
+	CacheTTL         *time.Duration // 0 disables the cache
+	LaunchProfiles   []LaunchProfile
+}
+
+// LaunchProfile defines how to launch ec2 instances
+type LaunchProfile struct {
+	Name             string
+	ImageID          string
+	InstanceType     string
+	SubnetID         string
+	SecurityGroupIDs []string
+	KeyName          string
+	Tags             map[string]string
+	UserData         string
 }
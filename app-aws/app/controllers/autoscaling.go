@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
+)
+
+func init() {
+	http.Handle("/autoscaling/groups/", util.Chain(util.APIResourceHandler(autoScalingGroups{})))
+}
+
+type autoScalingGroups struct {
+	util.APIResourceBase
+}
+
+type autoScalingCapacityRequest struct {
+	Desired       *int64 `json:"desired"`
+	HonorCooldown bool   `json:"honorCooldown"`
+}
+
+type autoScalingProcessRequest struct {
+	Processes []string `json:"processes"`
+	All       bool     `json:"all"`
+}
+
+// Get lists groups, or returns a group with its member instances with
+// "/autoscaling/groups/{name}" and its recent activities with "/{name}/activities"
+func (c autoScalingGroups) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	name, action := resourcePath(url, "/autoscaling/groups/")
+	switch {
+	case len(name) == 0:
+		groups, err := aws.AutoScalingGroups(nil)
+		if err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), groups
+	case action == "activities":
+		activities, err := aws.AutoScalingActivities(name, int64(misc.Atoi(queries.Get("limit"))))
+		if err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), activities
+	case len(action) != 0:
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	return autoScalingGroup(name)
+}
+
+// Put changes the desired capacity with "/autoscaling/groups/{name}/capacity"
+// and {"desired": 3, "honorCooldown": true}
+func (c autoScalingGroups) Put(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	name, action := resourcePath(url, "/autoscaling/groups/")
+	if len(name) == 0 || action != "capacity" {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	req := &autoScalingCapacityRequest{}
+	if err := misc.ReadMBJSON(body, req, 1); err != nil {
+		logs.Error.Printf("Could not decode request body as a json. Error: %v", err)
+		return util.Fail(http.StatusBadRequest, err.Error()), nil
+	}
+	if req.Desired == nil {
+		return util.Fail(http.StatusBadRequest, "desired is required"), nil
+	}
+	err := aws.AutoScalingSetCapacity(name, *req.Desired, req.HonorCooldown)
+	switch {
+	case err == aws.ErrNoSuchGroup:
+		return util.FailSimple(http.StatusNotFound), nil
+	case err == aws.ErrCapacityOutOfRange:
+		return util.Fail(http.StatusBadRequest, err.Error()), nil
+	case err != nil:
+		return failed(err), nil
+	}
+	return autoScalingGroup(name)
+}
+
+// Post suspends or resumes processes with "/autoscaling/groups/{name}/suspend"
+// or "/resume" and {"processes": ["Launch", "Terminate"]}, or all of them only
+// with {"all": true} or {"processes": ["*"]}
+func (c autoScalingGroups) Post(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	name, action := resourcePath(url, "/autoscaling/groups/")
+	if len(name) == 0 || (action != "suspend" && action != "resume") {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	req := &autoScalingProcessRequest{}
+	if err := misc.ReadMBJSON(body, req, 1); err != nil && err != io.EOF {
+		logs.Error.Printf("Could not decode request body as a json. Error: %v", err)
+		return util.Fail(http.StatusBadRequest, err.Error()), nil
+	}
+	if len(req.Processes) == 1 && req.Processes[0] == "*" {
+		req.All, req.Processes = true, nil
+	}
+	switch {
+	case req.All && len(req.Processes) != 0:
+		return util.Fail(http.StatusBadRequest, "processes cannot be given with all"), nil
+	case !req.All && len(req.Processes) == 0:
+		return util.Fail(http.StatusBadRequest, "processes is required"), nil
+	}
+	for _, process := range req.Processes {
+		if !aws.AutoScalingValidProcess(process) {
+			return util.Fail(http.StatusBadRequest, process+" is not a scaling process"), nil
+		}
+	}
+	var err error
+	if action == "suspend" {
+		err = aws.AutoScalingSuspend(name, req.Processes)
+	} else {
+		err = aws.AutoScalingResume(name, req.Processes)
+	}
+	if err != nil {
+		return failed(err), nil
+	}
+	return autoScalingGroup(name)
+}
+
+func autoScalingGroup(name string) (util.APIStatus, interface{}) {
+	group, err := aws.AutoScalingGroupByName(name)
+	if err != nil {
+		return failed(err), nil
+	}
+	if group == nil {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	return util.Success(http.StatusOK), group
+}
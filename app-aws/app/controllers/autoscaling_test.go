@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAutoScalingProcessesRequired(t *testing.T) {
+	server := httptest.NewServer(http.DefaultServeMux)
+	defer server.Close()
+
+	for _, body := range []string{"", `{}`, `{"processes": []}`, `{"all": false}`, `{"all": true, "processes": ["Launch"]}`} {
+		res, err := http.Post(server.URL+"/autoscaling/groups/web/suspend", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+			continue
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected %v for %q, but got %v", http.StatusBadRequest, body, res.StatusCode)
+		}
+	}
+}
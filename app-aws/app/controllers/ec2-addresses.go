@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
+)
+
+func init() {
+	http.Handle("/ec2/addresses/", util.Chain(util.APIResourceHandler(ec2Addresses{})))
+	http.Handle("/ec2/key-pairs/", util.Chain(util.APIResourceHandler(ec2KeyPairs{})))
+}
+
+type ec2Addresses struct {
+	util.APIResourceBase
+}
+
+type ec2KeyPairs struct {
+	util.APIResourceBase
+}
+
+type ec2AssociateRequest struct {
+	InstanceID string `json:"instanceId"`
+}
+
+func (c ec2Addresses) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	// retrive a specified address
+	if id, _ := resourcePath(url, "/ec2/addresses/"); len(id) != 0 {
+		return ec2Address(id)
+	}
+	// list addresses, e.g. ?unassociated=true for the idle ones
+	addresses, err := aws.Ec2Addresses(nil)
+	if err != nil {
+		return failed(err), nil
+	}
+	if misc.ParseBool(queries.Get("unassociated")) {
+		idle := []*aws.Ec2Address{}
+		for _, address := range addresses {
+			if address.Unassociated {
+				idle = append(idle, address)
+			}
+		}
+		addresses = idle
+	}
+	return util.Success(http.StatusOK), addresses
+}
+
+// Post associates an address with {"instanceId": "i-1"} or disassociates it
+// with "/ec2/addresses/{allocation id}/associate" or "/disassociate"
+func (c ec2Addresses) Post(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, action := resourcePath(url, "/ec2/addresses/")
+	if len(id) == 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	var err error
+	switch action {
+	case "associate":
+		req := &ec2AssociateRequest{}
+		if err = misc.ReadMBJSON(body, req, 1); err != nil {
+			logs.Error.Printf("Could not decode request body as a json. Error: %v", err)
+			return util.Fail(http.StatusBadRequest, err.Error()), nil
+		}
+		if req.InstanceID == "" {
+			return util.Fail(http.StatusBadRequest, "instanceId is required"), nil
+		}
+		err = aws.Ec2AssociateAddress(id, req.InstanceID)
+	case "disassociate":
+		err = aws.Ec2DisassociateAddress(id)
+	default:
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	if err != nil {
+		return failed(err), nil
+	}
+	return ec2Address(id)
+}
+
+// Delete releases an address
+func (c ec2Addresses) Delete(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, action := resourcePath(url, "/ec2/addresses/")
+	if len(id) == 0 || len(action) != 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	if err := aws.Ec2ReleaseAddress(id); err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), nil
+}
+
+func ec2Address(id string) (util.APIStatus, interface{}) {
+	address, err := aws.Ec2AddressByID(id)
+	if err != nil {
+		return failed(err), nil
+	}
+	if address == nil {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	return util.Success(http.StatusOK), address
+}
+
+func (c ec2KeyPairs) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	var names []string
+	if name, _ := resourcePath(url, "/ec2/key-pairs/"); len(name) != 0 {
+		names = []string{name}
+	}
+	keys, err := aws.Ec2KeyPairs(names)
+	if err != nil {
+		return failed(err), nil
+	}
+	if len(names) != 0 {
+		if len(keys) == 0 {
+			return util.FailSimple(http.StatusNotFound), nil
+		}
+		return util.Success(http.StatusOK), keys[0]
+	}
+	return util.Success(http.StatusOK), keys
+}
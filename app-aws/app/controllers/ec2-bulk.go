@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/jobs"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
+)
+
+func init() {
+	http.Handle("/ec2/bulk/", util.Chain(util.APIResourceHandler(ec2Bulk{})))
+}
+
+type ec2Bulk struct {
+	util.APIResourceBase
+}
+
+type ec2BulkRequest struct {
+	InstanceIDs []string `json:"instanceIds"`
+}
+
+type ec2BulkResult struct {
+	InstanceID string                   `json:"instanceId"`
+	Change     *ec2.InstanceStateChange `json:"change,omitempty"`
+	DryRun     bool                     `json:"dryRun,omitempty"`
+	Error      string                   `json:"error,omitempty"`
+}
+
+var ec2BulkActions = map[string]func(id string, dryRun bool) (*ec2.InstanceStateChange, error){
+	"start":  aws.Ec2StartInstance,
+	"stop":   aws.Ec2StopInstance,
+	"reboot": aws.Ec2RebootInstance,
+}
+
+// Post starts, stops or reboots many instances as a job with
+// "/ec2/bulk/{start|stop|reboot}" and {"instanceIds": ["i-1", "i-2"]}.
+// It answers 202 Accepted, and the job can be followed with "/jobs/{id}".
+func (c ec2Bulk) Post(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	name, rest := resourcePath(url, "/ec2/bulk/")
+	action, found := ec2BulkActions[name]
+	if !found || len(rest) != 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	req := &ec2BulkRequest{}
+	if err := misc.ReadMBJSON(body, req, 1); err != nil {
+		logs.Error.Printf("Could not decode request body as a json. Error: %v", err)
+		return util.Fail(http.StatusBadRequest, err.Error()), nil
+	}
+	if len(req.InstanceIDs) == 0 {
+		return util.Fail(http.StatusBadRequest, "instanceIds are required"), nil
+	}
+	dryRun := misc.ParseBool(queries.Get("dryrun"))
+
+	return accepted("ec2-"+name, func(ctx context.Context, progress jobs.Progress) (interface{}, error) {
+		results := []*ec2BulkResult{}
+		for idx, id := range req.InstanceIDs {
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+			result := &ec2BulkResult{InstanceID: id}
+			change, err := action(id, dryRun)
+			switch {
+			case aws.Ec2DryRunSucceeded(err):
+				result.DryRun = true
+			case err != nil:
+				result.Error = err.Error()
+			default:
+				result.Change = change
+			}
+			results = append(results, result)
+			progress(idx+1, len(req.InstanceIDs))
+		}
+		return results, nil
+	})
+}
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	"github.com/pottava/golang-microservices/app-aws/app/config"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+)
+
+func init() {
+	http.Handle("/ec2/costs", util.Chain(util.APIResourceHandler(ec2Costs{})))
+}
+
+type ec2Costs struct {
+	util.APIResourceBase
+}
+
+// Get estimates costs of instances in the configured regions and accounts,
+// e.g. ?tag=CostCenter&regions=ap-northeast-1&state=running
+func (c ec2Costs) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	path := config.NewConfig().PricingFile
+	pricing, err := aws.LoadPricing(path)
+	if err != nil {
+		logs.Error.Printf("Could not load the pricing table from %s. Error: %v", path, err)
+		return util.Fail(http.StatusServiceUnavailable, "pricing table is not available"), nil
+	}
+	targets, err := aws.Targets(csvValues(queries, "regions"))
+	if err != nil {
+		return util.Fail(http.StatusBadRequest, err.Error()), nil
+	}
+	return util.Success(http.StatusOK), aws.Ec2Costs(ec2InstanceQuery(queries), targets, pricing, queries.Get("tag"))
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
+)
+
+func init() {
+	http.Handle("/ec2/images/", util.Chain(util.APIResourceHandler(ec2Images{})))
+}
+
+type ec2Images struct {
+	util.APIResourceBase
+}
+
+func (c ec2Images) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	// retrive a specified image
+	if id, _ := resourcePath(url, "/ec2/images/"); len(id) != 0 {
+		image, err := aws.Ec2ImageByID(id)
+		if err != nil {
+			return failed(err), nil
+		}
+		if image == nil {
+			return util.FailSimple(http.StatusNotFound), nil
+		}
+		return util.Success(http.StatusOK), image
+	}
+	// list images, e.g. ?unused=true for the ones no instances are running from
+	images, err := aws.Ec2Images()
+	if err != nil {
+		return failed(err), nil
+	}
+	if misc.ParseBool(queries.Get("unused")) {
+		unused := []*aws.Ec2Image{}
+		for _, image := range images {
+			if image.Usage == 0 {
+				unused = append(unused, image)
+			}
+		}
+		images = unused
+	}
+	return util.Success(http.StatusOK), images
+}
+
+// Delete deregisters an image and deletes its orphaned snapshots, e.g. ?force=true&dryrun=true
+func (c ec2Images) Delete(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, action := resourcePath(url, "/ec2/images/")
+	if len(id) == 0 || len(action) != 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	result, err := aws.Ec2DeregisterImage(id, misc.ParseBool(queries.Get("force")), misc.ParseBool(queries.Get("dryrun")))
+	if err == aws.ErrImageInUse {
+		return util.Fail(http.StatusConflict, id+" is still used by instances, add force=true to deregister it anyway"), nil
+	}
+	if err != nil {
+		return failed(err), nil
+	}
+	if result == nil {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	return util.Success(http.StatusOK), result
+}
@@ -0,0 +1,45 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
+)
+
+type ec2LaunchDryRun struct {
+	Request aws.Ec2LaunchRequest `json:"request"`
+	DryRun  bool                 `json:"dryRun"`
+}
+
+// ec2Launch launches instances from a launch profile with overrides, e.g.
+// {"profile": "web", "count": 2, "instanceType": "t2.small", "tags": {"Owner": "me"}}
+// An invalid request is answered with its problems field by field, e.g.
+// [{"field": "imageId", "message": "must be an AMI ID like ami-xxxxxxxx"}]
+func ec2Launch(body io.Reader, dryRun bool) (util.APIStatus, interface{}) {
+	req := aws.Ec2LaunchRequest{}
+	if err := misc.ReadMBJSON(body, &req, 1); err != nil {
+		logs.Error.Printf("Could not decode request body as a json. Error: %v", err)
+		return util.Fail(http.StatusBadRequest, err.Error()), nil
+	}
+	profile, found := aws.Ec2LaunchProfile(req.Profile)
+	if !found {
+		return util.Fail(http.StatusBadRequest, "invalid launch request"),
+			[]*aws.Ec2LaunchProblem{&aws.Ec2LaunchProblem{Field: "profile", Message: req.Profile + " is not defined"}}
+	}
+	req.Merge(profile)
+	if problems := req.Validate(); len(problems) != 0 {
+		return util.Fail(http.StatusBadRequest, "invalid launch request"), problems
+	}
+	result, err := aws.Ec2Launch(req, dryRun)
+	if aws.Ec2DryRunSucceeded(err) {
+		return util.Success(http.StatusOK), ec2LaunchDryRun{Request: req, DryRun: true}
+	}
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusCreated), result
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type launchProblemsEnvelope struct {
+	Response []struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"response"`
+}
+
+func TestEc2LaunchWithoutTrailingSlash(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "launch")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+	ioutil.WriteFile(path, []byte(`{"LaunchProfiles": [{"Name": "web", "InstanceType": "t2.micro"}]}`), 0644)
+	original := os.Getenv("CONFIG_FILE_PATH")
+	os.Setenv("CONFIG_FILE_PATH", path)
+	defer os.Setenv("CONFIG_FILE_PATH", original)
+
+	server := httptest.NewServer(http.DefaultServeMux)
+	defer server.Close()
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	res, err := client.Post(server.URL+"/ec2/instances", "application/json",
+		strings.NewReader(`{"profile": "web", "count": 100, "imageId": "foo"}`))
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected %v, but got %v", http.StatusBadRequest, res.StatusCode)
+		return
+	}
+	envelope := launchProblemsEnvelope{}
+	json.NewDecoder(res.Body).Decode(&envelope)
+	fields := []string{}
+	for _, problem := range envelope.Response {
+		if problem.Message == "" {
+			t.Errorf("Expected a message for %v, but got nothing", problem.Field)
+		}
+		fields = append(fields, problem.Field)
+	}
+	if strings.Join(fields, ",") != "count,imageId" {
+		t.Errorf("Expected problems of %v, but got %v", "count,imageId", fields)
+	}
+
+	res, err = client.Post(server.URL+"/ec2/instances", "application/json", strings.NewReader(`{"profile": "db"}`))
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	defer res.Body.Close()
+	envelope = launchProblemsEnvelope{}
+	json.NewDecoder(res.Body).Decode(&envelope)
+	if res.StatusCode != http.StatusBadRequest || len(envelope.Response) != 1 || envelope.Response[0].Field != "profile" {
+		t.Errorf("Expected an undefined profile to be reported, but got %v %v", res.StatusCode, envelope)
+	}
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+)
+
+// ec2Metrics returns datapoints of a metric of an instance.
+// e.g. ?name=CPUUtilization&statistic=Maximum&period=5m&from=3h&to=2016-01-02T15:04:05Z&points=200
+// "from" and "to" are either RFC3339 timestamps or durations before now,
+// and the last 3 hours are returned by default.
+func ec2Metrics(id string, queries url.Values) (util.APIStatus, interface{}) {
+	query := aws.Ec2MetricQuery{
+		Name:      queries.Get("name"),
+		Statistic: queries.Get("statistic"),
+	}
+	if period := queries.Get("period"); period != "" {
+		d, err := time.ParseDuration(period)
+		if err != nil || d < time.Minute {
+			return util.Fail(http.StatusBadRequest, "period must be a duration of 1m or longer like 5m"), nil
+		}
+		query.Period = d
+	}
+	if points := queries.Get("points"); points != "" {
+		n, err := strconv.Atoi(points)
+		if err != nil || n <= 0 {
+			return util.Fail(http.StatusBadRequest, "points must be a positive integer"), nil
+		}
+		query.MaxPoints = n
+	}
+	switch query.Statistic {
+	case "", cloudwatch.StatisticAverage, cloudwatch.StatisticSum, cloudwatch.StatisticMinimum,
+		cloudwatch.StatisticMaximum, cloudwatch.StatisticSampleCount:
+	default:
+		return util.Fail(http.StatusBadRequest, "statistic must be one of Average, Sum, Minimum, Maximum or SampleCount"), nil
+	}
+	var ok bool
+	if query.From, ok = metricTime(queries.Get("from")); !ok {
+		return util.Fail(http.StatusBadRequest, "from must be a RFC3339 time or a duration like 3h"), nil
+	}
+	if query.To, ok = metricTime(queries.Get("to")); !ok {
+		return util.Fail(http.StatusBadRequest, "to must be a RFC3339 time or a duration like 1h"), nil
+	}
+	metric, err := aws.Ec2Metrics(id, query)
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), metric
+}
+
+// metricTime parses a RFC3339 time or a duration before now
+func metricTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, true
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			d = -d
+		}
+		return time.Now().Add(-d), true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEc2MetricsRejectsInvalidQueries(t *testing.T) {
+	server := httptest.NewServer(http.DefaultServeMux)
+	defer server.Close()
+
+	for _, query := range []string{"period=five", "period=30s", "points=abc", "points=0", "points=-3"} {
+		res, err := http.Get(server.URL + "/ec2/instances/i-1/metrics?" + query)
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+			continue
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected %v for %v, but got %v", http.StatusBadRequest, query, res.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
+)
+
+func init() {
+	http.Handle("/ec2/security-groups/", util.Chain(util.APIResourceHandler(ec2SecurityGroups{})))
+}
+
+type ec2SecurityGroups struct {
+	util.APIResourceBase
+}
+
+func (c ec2SecurityGroups) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, action := resourcePath(url, "/ec2/security-groups/")
+	switch {
+	case id == "exposure":
+		// report rules open to the world, e.g. ?ports=22,3389
+		ports := aws.SensitivePorts
+		if candidates := csvValues(queries, "ports"); len(candidates) != 0 {
+			ports = []int64{}
+			for _, candidate := range candidates {
+				port, err := strconv.ParseInt(candidate, 10, 64)
+				if err != nil {
+					return util.Fail(http.StatusBadRequest, "invalid port: "+candidate), nil
+				}
+				ports = append(ports, port)
+			}
+		}
+		exposures, err := aws.Ec2ExposureReport(ports)
+		if err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), exposures
+
+	case len(action) != 0:
+		return util.FailSimple(http.StatusNotFound), nil
+
+	case len(id) != 0:
+		// retrive a specified security group
+		group, err := aws.Ec2SecurityGroupByID(id)
+		if err != nil {
+			return failed(err), nil
+		}
+		if group == nil {
+			return util.FailSimple(http.StatusNotFound), nil
+		}
+		return util.Success(http.StatusOK), group
+	}
+	// list security groups
+	groups, err := aws.Ec2SecurityGroups(csvValues(queries, "id"))
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), groups
+}
+
+func (c ec2SecurityGroups) Post(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	return ec2ChangeRule(url, body, aws.Ec2AddRule)
+}
+
+func (c ec2SecurityGroups) Delete(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	return ec2ChangeRule(url, body, aws.Ec2RevokeRule)
+}
+
+// ec2ChangeRule adds or revokes a rule with "/ec2/security-groups/{id}/rules", e.g.
+// {"egress": false, "protocol": "tcp", "fromPort": 443, "toPort": 443, "cidr": "10.0.0.0/8"}
+func ec2ChangeRule(url string, body io.Reader, change func(string, aws.Ec2Rule) error) (util.APIStatus, interface{}) {
+	id, action := resourcePath(url, "/ec2/security-groups/")
+	if len(id) == 0 || action != "rules" {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	rule := aws.Ec2Rule{}
+	if err := misc.ReadMBJSON(body, &rule, 1); err != nil {
+		logs.Error.Printf("Could not decode request body as a json. Error: %v", err)
+		return util.Fail(http.StatusBadRequest, err.Error()), nil
+	}
+	if message := ec2ValidateRule(&rule); message != "" {
+		return util.Fail(http.StatusBadRequest, message), nil
+	}
+	if err := change(id, rule); err != nil {
+		if err == aws.ErrNoSuchSecurityGroup {
+			return util.FailSimple(http.StatusNotFound), nil
+		}
+		return failed(err), nil
+	}
+	group, err := aws.Ec2SecurityGroupByID(id)
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), group
+}
+
+func ec2ValidateRule(rule *aws.Ec2Rule) string {
+	rule.Protocol = strings.ToLower(strings.TrimSpace(rule.Protocol))
+	switch rule.Protocol {
+	case "":
+		return "protocol is required"
+	case "all":
+		rule.Protocol = "-1"
+	case "tcp", "udp", "icmp", "icmpv6", "-1":
+	default:
+		return "protocol must be one of tcp, udp, icmp, icmpv6 or -1"
+	}
+	if (rule.CidrIP == "") == (rule.SourceGroupID == "") {
+		return "either cidr or sourceGroupId is required"
+	}
+	if (rule.Protocol == "tcp" || rule.Protocol == "udp") &&
+		(rule.FromPort < 0 || rule.ToPort > 65535 || rule.FromPort > rule.ToPort) {
+		return "fromPort and toPort must be a range in 0-65535"
+	}
+	return ""
+}
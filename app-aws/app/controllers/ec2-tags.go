@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
+)
+
+type ec2BulkTags struct {
+	InstanceIDs []string          `json:"ids"`
+	Tags        map[string]string `json:"tags"`
+}
+
+func ec2GetTags(id string) (util.APIStatus, interface{}) {
+	tags, err := aws.Ec2Tags(id)
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), tags
+}
+
+// ec2PutTags sets tags of an instance, and replaces all of them with replace
+func ec2PutTags(id string, body io.Reader, replace bool) (util.APIStatus, interface{}) {
+	tags := map[string]string{}
+	if err := misc.ReadMBJSON(body, &tags, 1); err != nil {
+		logs.Error.Printf("Could not decode request body as a json. Error: %v", err)
+		return util.Fail(http.StatusBadRequest, err.Error()), nil
+	}
+	if message := ec2ValidateTags(tags, replace); message != "" {
+		return util.Fail(http.StatusBadRequest, message), nil
+	}
+	var err error
+	if replace {
+		err = aws.Ec2ReplaceTags(id, tags)
+	} else {
+		err = aws.Ec2SetTags(id, tags)
+	}
+	if err != nil {
+		return failed(err), nil
+	}
+	return ec2GetTags(id)
+}
+
+// ec2DeleteTags deletes tags specified as ?keys=Owner,CostCenter
+func ec2DeleteTags(id string, queries url.Values) (util.APIStatus, interface{}) {
+	keys := csvValues(queries, "keys")
+	if len(keys) == 0 {
+		return util.Fail(http.StatusBadRequest, "keys are required"), nil
+	}
+	if err := aws.Ec2DeleteTags(id, keys); err != nil {
+		return failed(err), nil
+	}
+	return ec2GetTags(id)
+}
+
+// ec2PutBulkTags tags many instances at once, e.g. {"ids": ["i-1", "i-2"], "tags": {"Owner": "me"}}
+func ec2PutBulkTags(body io.Reader, replace bool) (util.APIStatus, interface{}) {
+	req := &ec2BulkTags{}
+	if err := misc.ReadMBJSON(body, req, 1); err != nil {
+		logs.Error.Printf("Could not decode request body as a json. Error: %v", err)
+		return util.Fail(http.StatusBadRequest, err.Error()), nil
+	}
+	if len(req.InstanceIDs) == 0 {
+		return util.Fail(http.StatusBadRequest, "ids are required"), nil
+	}
+	if message := ec2ValidateTags(req.Tags, replace); message != "" {
+		return util.Fail(http.StatusBadRequest, message), nil
+	}
+	return util.Success(http.StatusOK), aws.Ec2TagInstances(req.InstanceIDs, req.Tags, replace)
+}
+
+// ec2ValidateTags refuses replacing tags with nothing, which would delete all of them
+func ec2ValidateTags(tags map[string]string, replace bool) string {
+	if replace && len(tags) == 0 {
+		return aws.ErrNoTags.Error()
+	}
+	for key, value := range tags {
+		switch {
+		case len(strings.TrimSpace(key)) == 0:
+			return "tag keys must not be empty"
+		case strings.HasPrefix(key, "aws:"):
+			return fmt.Sprintf("tag key %s uses the reserved prefix aws:", key)
+		case len(key) > 127:
+			return fmt.Sprintf("tag key %s is longer than 127 characters", key)
+		case len(value) > 255:
+			return fmt.Sprintf("tag value of %s is longer than 255 characters", key)
+		}
+	}
+	return ""
+}
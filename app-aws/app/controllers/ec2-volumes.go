@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/jobs"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
+)
+
+func init() {
+	http.Handle("/ec2/volumes/", util.Chain(util.APIResourceHandler(ec2Volumes{})))
+	http.Handle("/ec2/snapshots/", util.Chain(util.APIResourceHandler(ec2Snapshots{})))
+}
+
+type ec2Volumes struct {
+	util.APIResourceBase
+}
+
+type ec2Snapshots struct {
+	util.APIResourceBase
+}
+
+type ec2SnapshotRequest struct {
+	Description string `json:"description"`
+}
+
+func (c ec2Volumes) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, action := resourcePath(url, "/ec2/volumes/")
+	if len(action) != 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	// retrive a specified volume
+	if len(id) != 0 {
+		volume, err := aws.Ec2Volume(id)
+		if err != nil {
+			return failed(err), nil
+		}
+		if volume == nil {
+			return util.FailSimple(http.StatusNotFound), nil
+		}
+		return util.Success(http.StatusOK), volume
+	}
+	// list volumes, e.g. ?instance=i-1
+	volumes, err := aws.Ec2Volumes(nil, queries.Get("instance"))
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), volumes
+}
+
+func (c ec2Snapshots) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, _ := resourcePath(url, "/ec2/snapshots/")
+	switch id {
+	case "":
+		// list snapshots, e.g. ?volume=vol-1&days=30 for the ones older than 30 days
+		snapshots, err := aws.Ec2Snapshots(queries.Get("volume"), ec2SnapshotsBefore(queries))
+		if err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), snapshots
+	case "retention":
+		return ec2Retention(queries, false)
+	}
+	return util.FailSimple(http.StatusNotFound), nil
+}
+
+func (c ec2Snapshots) Post(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	if id, _ := resourcePath(url, "/ec2/snapshots/"); id == "retention" {
+		return ec2Retention(queries, !misc.ParseBool(queries.Get("dryrun")))
+	}
+	return util.FailSimple(http.StatusNotFound), nil
+}
+
+func (c ec2Snapshots) Delete(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	// delete a specified snapshot
+	if id, _ := resourcePath(url, "/ec2/snapshots/"); len(id) != 0 {
+		if err := aws.Ec2DeleteSnapshot(id); err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), aws.Ec2SnapshotResult{SnapshotID: id, Success: true}
+	}
+	// delete snapshots older than specified days as a job, e.g. ?days=90
+	before := ec2SnapshotsBefore(queries)
+	if before.IsZero() {
+		return util.Fail(http.StatusBadRequest, "days is required"), nil
+	}
+	snapshots, err := aws.Ec2Snapshots(queries.Get("volume"), before)
+	if err != nil {
+		return failed(err), nil
+	}
+	if misc.ParseBool(queries.Get("dryrun")) {
+		return util.Success(http.StatusOK), snapshots
+	}
+	return accepted("ec2-snapshot-delete", func(ctx context.Context, progress jobs.Progress) (interface{}, error) {
+		return aws.Ec2DeleteSnapshots(ctx, snapshots, progress)
+	})
+}
+
+// ec2Retention computes a prune plan under a policy like ?daily=7&weekly=4,
+// and prunes snapshots as a job when asked
+func ec2Retention(queries url.Values, prune bool) (util.APIStatus, interface{}) {
+	policy := aws.Ec2RetentionPolicy{
+		Daily:  misc.Atoi(queries.Get("daily")),
+		Weekly: misc.Atoi(queries.Get("weekly")),
+	}
+	if policy.Daily < 0 || policy.Weekly < 0 || policy.Daily+policy.Weekly == 0 {
+		return util.Fail(http.StatusBadRequest, "daily or weekly has to be a positive number"), nil
+	}
+	plan, err := aws.Ec2Retention(queries.Get("volume"), policy)
+	if err != nil {
+		return failed(err), nil
+	}
+	if !prune {
+		return util.Success(http.StatusOK), plan
+	}
+	return accepted("ec2-snapshot-retention", func(ctx context.Context, progress jobs.Progress) (interface{}, error) {
+		results, err := aws.Ec2DeleteSnapshots(ctx, plan.Prune, progress)
+		plan.Results = results
+		return plan, err
+	})
+}
+
+func ec2SnapshotsBefore(queries url.Values) time.Time {
+	if days := misc.Atoi(queries.Get("days")); days > 0 {
+		return time.Now().AddDate(0, 0, -days)
+	}
+	return time.Time{}
+}
+
+// ec2SnapshotVolumes creates snapshots of all the volumes of an instance as a job
+func ec2SnapshotVolumes(id string, body io.Reader) (util.APIStatus, interface{}) {
+	req := &ec2SnapshotRequest{}
+	if err := misc.ReadMBJSON(body, req, 1); err != nil && err != io.EOF {
+		logs.Error.Printf("Could not decode request body as a json. Error: %v", err)
+		return util.Fail(http.StatusBadRequest, err.Error()), nil
+	}
+	if req.Description == "" {
+		req.Description = "Created from " + id
+	}
+	volumes, err := aws.Ec2Volumes(nil, id)
+	if err != nil {
+		return failed(err), nil
+	}
+	return accepted("ec2-snapshot", func(ctx context.Context, progress jobs.Progress) (interface{}, error) {
+		return aws.Ec2SnapshotVolumes(ctx, volumes, req.Description, progress)
+	})
+}
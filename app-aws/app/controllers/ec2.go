@@ -2,35 +2,242 @@
 package controllers
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
 	"github.com/pottava/golang-microservices/app-aws/app/aws"
 	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
 )
 
 func init() {
+	// without the trailing slash too, or POST /ec2/instances would be redirected and replayed as GET
+	http.Handle("/ec2/instances", util.Chain(util.APIResourceHandler(ec2Instances{})))
 	http.Handle("/ec2/instances/", util.Chain(util.APIResourceHandler(ec2Instances{})))
+	http.Handle("/ec2/status", util.Chain(util.APIResourceHandler(ec2Status{})))
 }
 
 type ec2Instances struct {
 	util.APIResourceBase
 }
 
+type ec2Status struct {
+	util.APIResourceBase
+}
+
+type ec2DryRun struct {
+	InstanceID string `json:"InstanceId"`
+	DryRun     bool   `json:"DryRun"`
+}
+
 func (c ec2Instances) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
-	// retrive a specified instance
-	if id := url[len("/ec2/instances/"):]; len(id) != 0 {
-		instance, err := aws.Ec2Instance(id)
+	id, action := ec2InstancePath(url)
+	switch {
+	case len(id) == 0:
+		break
+	case action == "tags":
+		return ec2GetTags(id)
+	case action == "status":
+		health, err := aws.Ec2Status(id)
+		if err != nil {
+			return failed(err), nil
+		}
+		if health == nil {
+			return util.FailSimple(http.StatusNotFound), nil
+		}
+		return util.Success(http.StatusOK), health
+	case action == "metrics":
+		return ec2Metrics(id, queries)
+	case len(action) != 0:
+		return util.FailSimple(http.StatusNotFound), nil
+	default:
+		// retrive a specified instance
+		instance, age, err := aws.Ec2InstanceCached(id)
 		if err != nil {
-			return util.Fail(http.StatusInternalServerError, err.Error()), nil
+			return failed(err), nil
 		}
-		return util.Success(http.StatusOK), instance
+		if instance == nil {
+			return util.FailSimple(http.StatusNotFound), nil
+		}
+		return cachedFor(age), instance
+	}
+	// list instances over regions and accounts
+	if regions := csvValues(queries, "regions"); len(regions) != 0 {
+		targets, err := aws.Targets(regions)
+		if err != nil {
+			return util.Fail(http.StatusBadRequest, err.Error()), nil
+		}
+		inventory, age := aws.Ec2InstancesInRegionsCached(ec2InstanceQuery(queries), targets)
+		return cachedFor(age), inventory
 	}
 	// list instances
-	instances, err := aws.Ec2Instances()
+	page, age, err := aws.Ec2InstancesCached(ec2InstanceQuery(queries))
+	if err != nil {
+		return failed(err), nil
+	}
+	return cachedFor(age), page
+}
+
+// Get summarizes status checks and scheduled events of all the instances
+func (c ec2Status) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	summary, age, err := aws.Ec2StatusSummaryCached()
+	if err != nil {
+		return failed(err), nil
+	}
+	return cachedFor(age), summary
+}
+
+func (c ec2Instances) Post(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, action := ec2InstancePath(url)
+	if len(id) == 0 {
+		return ec2Launch(body, misc.ParseBool(queries.Get("dryrun")))
+	}
+	if len(action) == 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	if action == "snapshots" {
+		return ec2SnapshotVolumes(id, body)
+	}
+	dryRun := misc.ParseBool(queries.Get("dryrun"))
+
+	var change *ec2.InstanceStateChange
+	var err error
+	switch action {
+	case "start":
+		change, err = aws.Ec2StartInstance(id, dryRun)
+	case "stop":
+		change, err = aws.Ec2StopInstance(id, dryRun)
+	case "reboot":
+		change, err = aws.Ec2RebootInstance(id, dryRun)
+	default:
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	return ec2StateChanged(id, change, err)
+}
+
+func (c ec2Instances) Put(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	return ec2Tagging(url, body, true)
+}
+
+func (c ec2Instances) Patch(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	return ec2Tagging(url, body, false)
+}
+
+// ec2Tagging handles tags of an instance with "/ec2/instances/{id}/tags",
+// or of many instances with "/ec2/instances/"
+func ec2Tagging(url string, body io.Reader, replace bool) (util.APIStatus, interface{}) {
+	id, action := ec2InstancePath(url)
+	switch {
+	case len(id) == 0:
+		return ec2PutBulkTags(body, replace)
+	case action == "tags":
+		return ec2PutTags(id, body, replace)
+	}
+	return util.FailSimple(http.StatusNotFound), nil
+}
+
+func (c ec2Instances) Delete(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, action := ec2InstancePath(url)
+	if len(id) != 0 && action == "tags" {
+		return ec2DeleteTags(id, queries)
+	}
+	if len(id) == 0 || len(action) != 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	change, err := aws.Ec2TerminateInstance(id, misc.ParseBool(queries.Get("dryrun")))
+	return ec2StateChanged(id, change, err)
+}
+
+func ec2StateChanged(id string, change *ec2.InstanceStateChange, err error) (util.APIStatus, interface{}) {
+	if aws.Ec2DryRunSucceeded(err) {
+		return util.Success(http.StatusOK), ec2DryRun{InstanceID: id, DryRun: true}
+	}
 	if err != nil {
-		return util.Fail(http.StatusInternalServerError, err.Error()), nil
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), change
+}
+
+// ec2InstanceQuery builds conditions to list instances from query parameters.
+// e.g. ?state=running,stopped&type=t2.micro&vpc=vpc-1&tag:Env=dev&limit=50&token=...
+// With "regions=all" or "regions=us-east-1,ap-northeast-1", they are aggregated
+// over the regions and the configured accounts instead.
+// All pages are walked unless a page is asked for with "limit" or "token",
+// and "all=true" walks all pages even then.
+func ec2InstanceQuery(queries url.Values) aws.Ec2InstanceQuery {
+	query := aws.Ec2InstanceQuery{
+		States:    csvValues(queries, "state"),
+		Types:     csvValues(queries, "type"),
+		VpcIDs:    csvValues(queries, "vpc"),
+		Tags:      map[string][]string{},
+		PageSize:  int64(misc.Atoi(queries.Get("limit"))),
+		NextToken: queries.Get("token"),
+	}
+	for key := range queries {
+		if strings.HasPrefix(key, "tag:") && len(key) > len("tag:") {
+			query.Tags[key[len("tag:"):]] = csvValues(queries, key)
+		}
+	}
+	query.AllPages = misc.ParseBool(queries.Get("all")) ||
+		(query.PageSize == 0 && query.NextToken == "")
+	return query
+}
+
+// csvValues collects comma separated values of a query parameter
+func csvValues(queries url.Values, key string) []string {
+	values := []string{}
+	for _, value := range queries[key] {
+		for _, v := range misc.ParseCsvLine(value) {
+			if v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// ec2InstancePath splits "/ec2/instances/{id}/{action}" into its id and action
+func ec2InstancePath(url string) (id, action string) {
+	return resourcePath(url, "/ec2/instances/")
+}
+
+// resourcePath splits "{prefix}{id}/{action}" into its id and action
+func resourcePath(url, prefix string) (id, action string) {
+	rest := strings.TrimPrefix(url, strings.TrimSuffix(prefix, "/"))
+	parts := strings.SplitN(strings.Trim(rest, "/"), "/", 2)
+	id = parts[0]
+	if len(parts) > 1 {
+		action = parts[1]
+	}
+	return id, action
+}
+
+// cachedFor tells clients how old a cached response is,
+// and how long they can keep it on their side
+func cachedFor(age time.Duration) util.APIStatus {
+	maxAge := aws.CacheTTL() - age
+	if maxAge <= 0 {
+		return util.Success(http.StatusOK).WithHeader("Cache-Control", "no-cache")
+	}
+	return util.Success(http.StatusOK).
+		WithHeader("Cache-Control", fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds()))).
+		WithHeader("Age", strconv.Itoa(int(age.Seconds())))
+}
+
+// failed converts client errors reported by AWS into the same status code,
+// and anything else into an internal server error
+func failed(err error) util.APIStatus {
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		if code := reqErr.StatusCode(); code >= 400 && code < 500 {
+			return util.Fail(code, reqErr.Message())
+		}
 	}
-	return util.Success(http.StatusOK), instances
+	return util.Fail(http.StatusInternalServerError, err.Error())
 }
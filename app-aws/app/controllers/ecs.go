@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+)
+
+func init() {
+	http.Handle("/ecs/clusters/", util.Chain(util.APIResourceHandler(ecsClusters{})))
+}
+
+type ecsClusters struct {
+	util.APIResourceBase
+}
+
+// Get returns clusters with "/ecs/clusters/", and then the resources of a cluster
+// with "/{cluster}/services", "/{cluster}/services/{service}",
+// "/{cluster}/services/{service}/tasks" or "/{cluster}/tasks"
+func (c ecsClusters) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	cluster, kind, service, action := ecsPath(url)
+	var result interface{}
+	var err error
+	switch {
+	case len(cluster) == 0:
+		result, err = aws.EcsClusters()
+	case kind == "tasks" && len(service) == 0:
+		result, err = aws.EcsTasks(cluster, "")
+	case kind != "services":
+		return util.FailSimple(http.StatusNotFound), nil
+	case len(service) == 0:
+		result, err = aws.EcsServices(cluster)
+	case action == "tasks":
+		result, err = aws.EcsTasks(cluster, service)
+	case len(action) != 0:
+		return util.FailSimple(http.StatusNotFound), nil
+	default:
+		found, err := aws.EcsService(cluster, service)
+		if err != nil {
+			return failed(err), nil
+		}
+		if found == nil {
+			return util.FailSimple(http.StatusNotFound), nil
+		}
+		return util.Success(http.StatusOK), found
+	}
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), result
+}
+
+// Post forces a new deployment of a service with
+// "/ecs/clusters/{cluster}/services/{service}/deployments"
+func (c ecsClusters) Post(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	cluster, kind, service, action := ecsPath(url)
+	if len(cluster) == 0 || kind != "services" || len(service) == 0 || action != "deployments" {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	updated, err := aws.EcsForceDeployment(cluster, service)
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), updated
+}
+
+// ecsPath splits "/ecs/clusters/{cluster}/{kind}/{service}/{action}" into its parts
+func ecsPath(url string) (cluster, kind, service, action string) {
+	cluster, rest := resourcePath(url, "/ecs/clusters/")
+	parts := strings.SplitN(rest, "/", 3)
+	kind = parts[0]
+	if len(parts) > 1 {
+		service = parts[1]
+	}
+	if len(parts) > 2 {
+		action = parts[2]
+	}
+	return cluster, kind, service, action
+}
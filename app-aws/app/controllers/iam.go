@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	"github.com/pottava/golang-microservices/app-aws/app/config"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+)
+
+func init() {
+	http.Handle("/iam/", util.Chain(util.APIResourceHandler(iamResources{})))
+}
+
+type iamResources struct {
+	util.APIResourceBase
+}
+
+// Get lists users with "/iam/users", roles with "/iam/roles", or flags
+// access keys older than maxAge days and console users without MFA with "/iam/audit?maxAge=90"
+func (c iamResources) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	resource, _ := resourcePath(url, "/iam/")
+	var result interface{}
+	var err error
+	switch resource {
+	case "users":
+		result, err = aws.IamUsers()
+	case "roles":
+		result, err = aws.IamRoles()
+	case "audit":
+		maxAge := config.NewConfig().IamKeyMaxAge
+		if days := queries.Get("maxAge"); days != "" {
+			if maxAge, err = strconv.Atoi(days); err != nil || maxAge <= 0 {
+				return util.Fail(http.StatusBadRequest, "maxAge must be a positive number of days"), nil
+			}
+		}
+		result, err = aws.IamAudit(maxAge)
+	default:
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), result
+}
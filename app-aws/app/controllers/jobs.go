@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/jobs"
+)
+
+func init() {
+	http.Handle("/jobs/", util.Chain(util.APIResourceHandler(jobResources{})))
+}
+
+type jobResources struct {
+	util.APIResourceBase
+}
+
+// Get returns progress, result and error of a job with "/jobs/{id}"
+func (c jobResources) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, action := resourcePath(url, "/jobs/")
+	if len(id) == 0 || len(action) != 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	job, err := jobs.Default.Get(id)
+	if err != nil {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	return util.Success(http.StatusOK), job
+}
+
+// Delete cancels a job
+func (c jobResources) Delete(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, action := resourcePath(url, "/jobs/")
+	if len(id) == 0 || len(action) != 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	job, err := jobs.Default.Cancel(id)
+	switch err {
+	case nil:
+		return util.Success(http.StatusOK), job
+	case jobs.ErrFinished:
+		return util.Fail(http.StatusConflict, err.Error()), nil
+	}
+	return util.FailSimple(http.StatusNotFound), nil
+}
+
+// accepted submits an operation as a job and tells where to follow it
+func accepted(kind string, work jobs.Work) (util.APIStatus, interface{}) {
+	job, err := jobs.Default.Submit(kind, work)
+	if err != nil {
+		return util.Fail(http.StatusServiceUnavailable, err.Error()), nil
+	}
+	return util.Success(http.StatusAccepted).WithHeader("Location", "/jobs/"+job.ID), job
+}
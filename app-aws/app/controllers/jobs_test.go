@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/jobs"
+)
+
+type acceptedResource struct {
+	util.APIResourceBase
+}
+
+func (c acceptedResource) Post(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	return accepted("test", func(ctx context.Context, progress jobs.Progress) (interface{}, error) {
+		return nil, nil
+	})
+}
+
+func TestAcceptedTellsWhereTheJobIs(t *testing.T) {
+	server := httptest.NewServer(util.APIResourceHandler(acceptedResource{}))
+	defer server.Close()
+
+	res, err := http.Post(server.URL, "application/json", nil)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusAccepted {
+		t.Errorf("Expected %v, but got %v", http.StatusAccepted, res.StatusCode)
+	}
+	location := res.Header.Get("Location")
+	if !strings.HasPrefix(location, "/jobs/") {
+		t.Errorf("Expected the location of the job, but got %q", location)
+		return
+	}
+	if _, err = jobs.Default.Get(strings.TrimPrefix(location, "/jobs/")); err != nil {
+		t.Errorf("Expected the job at %v, but got %v", location, err)
+	}
+}
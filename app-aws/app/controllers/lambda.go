@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
+)
+
+func init() {
+	http.Handle("/lambda/functions/", util.Chain(util.APIResourceHandler(lambdaFunctions{})))
+}
+
+type lambdaFunctions struct {
+	util.APIResourceBase
+}
+
+// Get lists functions, or returns the configuration of one with "/lambda/functions/{name}"
+func (c lambdaFunctions) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	name, action := resourcePath(url, "/lambda/functions/")
+	if len(name) == 0 {
+		functions, err := aws.LambdaFunctions()
+		if err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), functions
+	}
+	if len(action) != 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	function, err := aws.LambdaFunctionByName(name)
+	if err != nil {
+		return failed(err), nil
+	}
+	if function == nil {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	return util.Success(http.StatusOK), function
+}
+
+// Post invokes a function synchronously with "/lambda/functions/{name}/invoke",
+// whose body is passed to the function as its event. A version or an alias
+// can be specified with ?qualifier=.
+func (c lambdaFunctions) Post(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	name, action := resourcePath(url, "/lambda/functions/")
+	if len(name) == 0 || action != "invoke" {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	// synchronous invocations accept 6MB of payload at most
+	payload, err := misc.ReadMB(body, 6)
+	if err != nil {
+		logs.Error.Printf("Could not read request body. Error: %v", err)
+		return util.Fail(http.StatusBadRequest, err.Error()), nil
+	}
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+	var event interface{}
+	if err = json.Unmarshal(payload, &event); err != nil {
+		return util.Fail(http.StatusBadRequest, "payload must be a json"), nil
+	}
+	invocation, err := aws.LambdaInvoke(name, queries.Get("qualifier"), payload)
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), invocation
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+)
+
+func init() {
+	http.Handle("/network/", util.Chain(util.APIResourceHandler(network{})))
+}
+
+type network struct {
+	util.APIResourceBase
+}
+
+// Get serves vpcs, subnets, route-tables, gateways and topology under /network/,
+// which can be narrowed down to a VPC with ?vpc=vpc-1
+func (c network) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	resource, id := resourcePath(url, "/network/")
+	vpc := queries.Get("vpc")
+
+	var data interface{}
+	var err error
+	switch resource {
+	case "vpcs":
+		if len(id) != 0 {
+			return networkVpc(id)
+		}
+		data, err = aws.Ec2Vpcs(nil)
+	case "subnets":
+		data, err = aws.Ec2Subnets(vpc)
+	case "route-tables":
+		data, err = aws.Ec2RouteTables(vpc)
+	case "gateways":
+		data, err = aws.Ec2GatewaysOf(vpc)
+	case "topology":
+		data, err = aws.NetworkTopology(vpc)
+	default:
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), data
+}
+
+func networkVpc(id string) (util.APIStatus, interface{}) {
+	vpcs, err := aws.Ec2Vpcs([]string{id})
+	if err != nil {
+		return failed(err), nil
+	}
+	if len(vpcs) == 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	return util.Success(http.StatusOK), vpcs[0]
+}
@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
+)
+
+func init() {
+	http.Handle("/rds/instances/", util.Chain(util.APIResourceHandler(rdsInstances{})))
+	http.Handle("/rds/clusters/", util.Chain(util.APIResourceHandler(rdsClusters{})))
+	http.Handle("/rds/snapshots/", util.Chain(util.APIResourceHandler(rdsSnapshots{})))
+}
+
+type rdsInstances struct {
+	util.APIResourceBase
+}
+
+type rdsClusters struct {
+	util.APIResourceBase
+}
+
+type rdsSnapshots struct {
+	util.APIResourceBase
+}
+
+type rdsSnapshotRequest struct {
+	SnapshotID string `json:"snapshotId"`
+}
+
+func (c rdsInstances) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, action := resourcePath(url, "/rds/instances/")
+	if len(action) != 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	// retrive a specified instance
+	if len(id) != 0 {
+		instance, err := aws.RdsInstanceByID(id)
+		if err != nil {
+			return failed(err), nil
+		}
+		if instance == nil {
+			return util.FailSimple(http.StatusNotFound), nil
+		}
+		return util.Success(http.StatusOK), instance
+	}
+	instances, err := aws.RdsInstances()
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), instances
+}
+
+// Post starts or stops an instance tagged as a non-production one with "/rds/instances/{id}/start"
+// or "/stop", or creates a manual snapshot with "/snapshots" and {"snapshotId": "..."}
+func (c rdsInstances) Post(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, action := resourcePath(url, "/rds/instances/")
+	if len(id) == 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	if action == "snapshots" {
+		req := &rdsSnapshotRequest{}
+		if err := misc.ReadMBJSON(body, req, 1); err != nil && err != io.EOF {
+			logs.Error.Printf("Could not decode request body as a json. Error: %v", err)
+			return util.Fail(http.StatusBadRequest, err.Error()), nil
+		}
+		snapshot, err := aws.RdsCreateSnapshot(id, req.SnapshotID)
+		if err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), snapshot
+	}
+	var instance *rds.DBInstance
+	var err error
+	switch action {
+	case "start":
+		instance, err = aws.RdsStartInstance(id)
+	case "stop":
+		instance, err = aws.RdsStopInstance(id)
+	default:
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	switch err {
+	case aws.ErrNoSuchDBInstance:
+		return util.FailSimple(http.StatusNotFound), nil
+	case aws.ErrProduction:
+		return util.Fail(http.StatusConflict, id+" is not tagged as a non-production database"), nil
+	}
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), instance
+}
+
+func (c rdsClusters) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, action := resourcePath(url, "/rds/clusters/")
+	if len(action) != 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	// retrive a specified cluster
+	if len(id) != 0 {
+		cluster, err := aws.RdsClusterByID(id)
+		if err != nil {
+			return failed(err), nil
+		}
+		if cluster == nil {
+			return util.FailSimple(http.StatusNotFound), nil
+		}
+		return util.Success(http.StatusOK), cluster
+	}
+	clusters, err := aws.RdsClusters()
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), clusters
+}
+
+// Get lists snapshots oldest first, e.g. ?instance=db-1&days=30 for the ones older than 30 days,
+// or returns a snapshot with "/rds/snapshots/{id}"
+func (c rdsSnapshots) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, action := resourcePath(url, "/rds/snapshots/")
+	if len(action) != 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	if len(id) != 0 {
+		snapshot, err := aws.RdsSnapshotByID(id)
+		if err != nil {
+			return failed(err), nil
+		}
+		if snapshot == nil {
+			return util.FailSimple(http.StatusNotFound), nil
+		}
+		return util.Success(http.StatusOK), snapshot
+	}
+	olderThan := time.Duration(misc.Atoi(queries.Get("days"))) * 24 * time.Hour
+	snapshots, err := aws.RdsSnapshots(queries.Get("instance"), olderThan)
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), snapshots
+}
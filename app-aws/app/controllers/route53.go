@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/route53"
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
+)
+
+func init() {
+	http.Handle("/route53/zones/", util.Chain(util.APIResourceHandler(route53Zones{})))
+	http.Handle("/route53/changes/", util.Chain(util.APIResourceHandler(route53Changes{})))
+}
+
+type route53Zones struct {
+	util.APIResourceBase
+}
+
+type route53Changes struct {
+	util.APIResourceBase
+}
+
+// route53ChangeResult is an applied change, with the error of waiting
+// for it to propagate if any
+type route53ChangeResult struct {
+	*route53.ChangeInfo
+	WaitError string `json:"waitError,omitempty"`
+}
+
+type route53DryRun struct {
+	Batch  *aws.Route53ChangeBatch `json:"batch"`
+	DryRun bool                    `json:"dryRun"`
+}
+
+// How long a change request can wait for the change to propagate
+const route53MaxWait = 2 * time.Minute
+
+// Get lists hosted zones, e.g. ?name=example.com, returns one with "/route53/zones/{id}",
+// or its record sets with "/{id}/records?name=www&type=A"
+func (c route53Zones) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, action := resourcePath(url, "/route53/zones/")
+	switch {
+	case len(id) == 0:
+		zones, err := aws.Route53Zones(queries.Get("name"))
+		if err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), zones
+	case action == "records":
+		records, err := aws.Route53Records(id, queries.Get("name"), queries.Get("type"))
+		if err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), records
+	case len(action) != 0:
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	zone, err := aws.Route53Zone(id)
+	if err != nil {
+		return failed(err), nil
+	}
+	if zone == nil {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	return util.Success(http.StatusOK), zone
+}
+
+// Post validates and applies UPSERT/DELETE changes to a hosted zone with
+// "/route53/zones/{id}/changes", whose body is described at aws.Route53ChangeBatch.
+// With ?wait=60s it waits for the change to propagate, and ?dryrun=true
+// only validates the changes.
+func (c route53Zones) Post(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, action := resourcePath(url, "/route53/zones/")
+	if len(id) == 0 || action != "changes" {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	batch := &aws.Route53ChangeBatch{}
+	if err := misc.ReadMBJSON(body, batch, 1); err != nil {
+		logs.Error.Printf("Could not decode request body as a json. Error: %v", err)
+		return util.Fail(http.StatusBadRequest, err.Error()), nil
+	}
+	zone, err := aws.Route53Zone(id)
+	if err != nil {
+		return failed(err), nil
+	}
+	if zone == nil {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	batch.Normalize(*zone.Name)
+	problems, err := batch.Complete(id)
+	if err != nil {
+		return failed(err), nil
+	}
+	if len(problems) == 0 {
+		problems = batch.Validate(*zone.Name)
+	}
+	if len(problems) != 0 {
+		return util.Fail(http.StatusBadRequest, strings.Join(problems, ", ")), nil
+	}
+	if misc.ParseBool(queries.Get("dryrun")) {
+		return util.Success(http.StatusOK), route53DryRun{Batch: batch, DryRun: true}
+	}
+	change, err := aws.Route53ApplyChanges(id, batch)
+	if err != nil {
+		return failed(err), nil
+	}
+	result := route53ChangeResult{ChangeInfo: change}
+	if wait := misc.ParseDuration(queries.Get("wait")); wait > 0 {
+		if wait > route53MaxWait {
+			wait = route53MaxWait
+		}
+		// the change has been applied even when it could not be waited for
+		if waited, err := aws.Route53WaitForChange(*change.Id, wait); err != nil {
+			logs.Warn.Printf("Could not wait for %s to propagate. Error: %v", *change.Id, err)
+			result.WaitError = err.Error()
+		} else {
+			result.ChangeInfo = waited
+		}
+	}
+	return util.Success(http.StatusOK), result
+}
+
+// Get returns whether a change has propagated with "/route53/changes/{id}"
+func (c route53Changes) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	id, action := resourcePath(url, "/route53/changes/")
+	if len(id) == 0 || len(action) != 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	change, err := aws.Route53ChangeStatus(id)
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), change
+}
@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
+)
+
+func init() {
+	http.Handle("/s3/buckets/", util.Chain(util.APIResourceHandler(s3Buckets{})))
+}
+
+type s3Buckets struct {
+	util.APIResourceBase
+}
+
+type s3PresignedURL struct {
+	Method  string    `json:"method"`
+	URL     string    `json:"url"`
+	Expires time.Time `json:"expires"`
+}
+
+// Get serves buckets and their contents. Objects are listed with
+// /s3/buckets/{bucket}/objects?prefix=logs/&delimiter=/&limit=100&token=...
+// and "key" instead returns metadata of an object. Presigned URLs are made with
+// /s3/buckets/{bucket}/presign?key=logs/app.log&method=PUT&expires=15m
+func (c s3Buckets) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	bucket, action := resourcePath(url, "/s3/buckets/")
+	if len(bucket) == 0 {
+		buckets, err := aws.S3Buckets()
+		if err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), buckets
+	}
+	key := queries.Get("key")
+
+	switch action {
+	case "objects":
+		if key != "" {
+			metadata, err := aws.S3Object(bucket, key)
+			if err != nil {
+				return failed(err), nil
+			}
+			if metadata == nil {
+				return util.FailSimple(http.StatusNotFound), nil
+			}
+			return util.Success(http.StatusOK), metadata
+		}
+		page, err := aws.S3Objects(bucket, queries.Get("prefix"), queries.Get("delimiter"),
+			queries.Get("token"), int64(misc.Atoi(queries.Get("limit"))))
+		if err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), page
+
+	case "presign":
+		if key == "" {
+			return util.Fail(http.StatusBadRequest, "key is required"), nil
+		}
+		method := strings.ToUpper(misc.NVL(queries.Get("method"), "GET"))
+		if method != "GET" && method != "PUT" {
+			return util.Fail(http.StatusBadRequest, "method must be GET or PUT"), nil
+		}
+		expires := misc.ParseDuration(queries.Get("expires"))
+		if expires <= 0 {
+			expires = 15 * time.Minute
+		}
+		if expires > 7*24*time.Hour {
+			return util.Fail(http.StatusBadRequest, "expires must be 7 days or less"), nil
+		}
+		presigned, err := aws.S3PresignedURL(bucket, key, method, expires)
+		if err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), s3PresignedURL{Method: method, URL: presigned, Expires: time.Now().Add(expires)}
+
+	case "security":
+		security, err := aws.S3Security(bucket)
+		if err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), security
+	}
+	return util.FailSimple(http.StatusNotFound), nil
+}
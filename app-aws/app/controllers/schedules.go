@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
+	"github.com/pottava/golang-microservices/app-aws/app/scheduler"
+)
+
+func init() {
+	http.Handle("/schedules/", util.Chain(util.APIResourceHandler(schedules{})))
+}
+
+type schedules struct {
+	util.APIResourceBase
+}
+
+type scheduleDefinition struct {
+	Name       string              `json:"name"`
+	Expression string              `json:"expression"`
+	Schedule   *scheduler.Schedule `json:"schedule,omitempty"`
+	Message    string              `json:"message,omitempty"`
+}
+
+func (c schedules) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	name, _ := resourcePath(url, "/schedules/")
+	switch name {
+	case "":
+		// list stored definitions
+		definitions := []*scheduleDefinition{}
+		for name, expression := range scheduler.Default.Store.Defined() {
+			definitions = append(definitions, newScheduleDefinition(name, expression))
+		}
+		sort.Slice(definitions, func(i, j int) bool {
+			return definitions[i].Name < definitions[j].Name
+		})
+		return util.Success(http.StatusOK), definitions
+
+	case "preview":
+		// the next actions, e.g. ?hours=48
+		hours := misc.Atoi(queries.Get("hours"))
+		if hours <= 0 {
+			hours = 24
+		}
+		plans, err := scheduler.Default.Preview(time.Duration(hours) * time.Hour)
+		if err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), plans
+
+	case "history":
+		return util.Success(http.StatusOK), scheduler.Default.Store.History()
+	}
+	expression, found := scheduler.Default.Store.Defined()[name]
+	if !found {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	return util.Success(http.StatusOK), newScheduleDefinition(name, expression)
+}
+
+// Put stores a named definition, e.g. PUT /schedules/office-hours {"expression": "weekdays-09-19-Asia/Tokyo"}
+func (c schedules) Put(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	name, action := resourcePath(url, "/schedules/")
+	if len(name) == 0 || len(action) != 0 || name == "preview" || name == "history" {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	definition := &scheduleDefinition{}
+	if err := misc.ReadMBJSON(body, definition, 1); err != nil {
+		logs.Error.Printf("Could not decode request body as a json. Error: %v", err)
+		return util.Fail(http.StatusBadRequest, err.Error()), nil
+	}
+	if _, err := scheduler.Parse(definition.Expression); err != nil {
+		return util.Fail(http.StatusBadRequest, err.Error()), nil
+	}
+	if err := scheduler.Default.Store.Define(name, definition.Expression); err != nil {
+		return util.Fail(http.StatusInternalServerError, err.Error()), nil
+	}
+	return util.Success(http.StatusOK), newScheduleDefinition(name, definition.Expression)
+}
+
+func (c schedules) Delete(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	name, _ := resourcePath(url, "/schedules/")
+	if _, found := scheduler.Default.Store.Defined()[name]; !found {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	if err := scheduler.Default.Store.Undefine(name); err != nil {
+		return util.Fail(http.StatusInternalServerError, err.Error()), nil
+	}
+	return util.Success(http.StatusOK), nil
+}
+
+func newScheduleDefinition(name, expression string) *scheduleDefinition {
+	definition := &scheduleDefinition{Name: name, Expression: expression}
+	schedule, err := scheduler.Parse(expression)
+	if err != nil {
+		definition.Message = err.Error()
+		return definition
+	}
+	definition.Schedule = schedule
+	return definition
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
+)
+
+func init() {
+	http.Handle("/sns/topics/", util.Chain(util.APIResourceHandler(snsTopics{})))
+}
+
+type snsTopics struct {
+	util.APIResourceBase
+}
+
+type snsPublishRequest struct {
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+}
+
+type snsPublishResult struct {
+	MessageID string `json:"messageId"`
+}
+
+// Get lists topics, or subscriptions of a topic with "/sns/topics/{arn}/subscriptions"
+func (c snsTopics) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	arn, action := resourcePath(url, "/sns/topics/")
+	switch {
+	case len(arn) == 0:
+		topics, err := aws.SnsTopics()
+		if err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), topics
+	case action == "subscriptions":
+		subscriptions, err := aws.SnsSubscriptions(arn)
+		if err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), subscriptions
+	}
+	return util.FailSimple(http.StatusNotFound), nil
+}
+
+// Post publishes a test message with "/sns/topics/{arn}/publish"
+// and {"subject": "...", "message": "..."}
+func (c snsTopics) Post(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	arn, action := resourcePath(url, "/sns/topics/")
+	if len(arn) == 0 || action != "publish" {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	req := &snsPublishRequest{}
+	if err := misc.ReadMBJSON(body, req, 1); err != nil && err != io.EOF {
+		logs.Error.Printf("Could not decode request body as a json. Error: %v", err)
+		return util.Fail(http.StatusBadRequest, err.Error()), nil
+	}
+	if req.Message == "" {
+		req.Message = "This is a test message from golang-microservices."
+	}
+	id, err := aws.SnsPublish(arn, req.Subject, req.Message)
+	if err != nil {
+		return failed(err), nil
+	}
+	return util.Success(http.StatusOK), snsPublishResult{MessageID: id}
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	util "github.com/pottava/golang-microservices/app-aws/app/http"
+	"github.com/pottava/golang-microservices/app-aws/app/jobs"
+	"github.com/pottava/golang-microservices/app-aws/app/misc"
+)
+
+func init() {
+	http.Handle("/sqs/queues/", util.Chain(util.APIResourceHandler(sqsQueues{})))
+}
+
+type sqsQueues struct {
+	util.APIResourceBase
+}
+
+// Get lists queues, e.g. ?prefix=app-, or returns a queue with its attributes
+// with "/sqs/queues/{name}"
+func (c sqsQueues) Get(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	name, action := resourcePath(url, "/sqs/queues/")
+	switch {
+	case len(name) == 0:
+		queues, err := aws.SqsQueues(queries.Get("prefix"))
+		if err != nil {
+			return failed(err), nil
+		}
+		return util.Success(http.StatusOK), queues
+	case len(action) != 0:
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	queue, err := aws.SqsQueueByName(name)
+	if err != nil {
+		return failed(err), nil
+	}
+	if queue == nil {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	return util.Success(http.StatusOK), queue
+}
+
+// Post purges a queue with "/sqs/queues/{name}/purge", peeks its messages with
+// "/{name}/peek?limit=10", or moves messages of a dead-letter queue back with
+// "/{name}/redrive?to={queue}" as a job. Peeking raises receive counts of the
+// messages, so it is not a GET. The destination of a redrive can be omitted
+// when only one queue uses the dead-letter queue.
+func (c sqsQueues) Post(url string, queries url.Values, body io.Reader) (util.APIStatus, interface{}) {
+	name, action := resourcePath(url, "/sqs/queues/")
+	if len(name) == 0 {
+		return util.FailSimple(http.StatusNotFound), nil
+	}
+	switch action {
+	case "peek":
+		peeked, err := aws.SqsPeek(name, misc.Atoi(queries.Get("limit")))
+		if err != nil {
+			return sqsFailed(err), nil
+		}
+		return util.Success(http.StatusOK), peeked
+	case "purge":
+		if err := aws.SqsPurge(name); err != nil {
+			return sqsFailed(err), nil
+		}
+		return util.Success(http.StatusOK), nil
+	case "redrive":
+		to := queries.Get("to")
+		if to == "" {
+			target, err := aws.SqsRedriveTarget(name)
+			if err == aws.ErrNoRedriveTarget || err == aws.ErrAmbiguousRedriveTarget {
+				return util.Fail(http.StatusBadRequest, err.Error()), nil
+			}
+			if err != nil {
+				return sqsFailed(err), nil
+			}
+			to = target
+		}
+		queue, err := aws.SqsQueueByName(name)
+		if err != nil {
+			return failed(err), nil
+		}
+		if queue == nil {
+			return util.FailSimple(http.StatusNotFound), nil
+		}
+		// the destination is confirmed here not to fail the job later
+		redrive, err := aws.NewSqsRedrive(name, to)
+		switch err {
+		case nil:
+		case aws.ErrRedriveToItself, aws.ErrRedriveFifoMismatch:
+			return util.Fail(http.StatusBadRequest, err.Error()), nil
+		case aws.ErrNoSuchQueue:
+			return util.Fail(http.StatusBadRequest, "queue "+to+" does not exist"), nil
+		default:
+			return failed(err), nil
+		}
+		return accepted("sqs-redrive", func(ctx context.Context, progress jobs.Progress) (interface{}, error) {
+			return redrive, redrive.Run(ctx, progress)
+		})
+	}
+	return util.FailSimple(http.StatusNotFound), nil
+}
+
+func sqsFailed(err error) util.APIStatus {
+	if err == aws.ErrNoSuchQueue {
+		return util.FailSimple(http.StatusNotFound)
+	}
+	return failed(err)
+}
@@ -26,6 +26,7 @@ type APIStatus struct {
 	success bool
 	code    int
 	message string
+	headers map[string]string
 }
 
 // APIResource represents RESTful API Interfaces
@@ -118,8 +119,10 @@ func APIResourceHandler(APIResource APIResource) http.HandlerFunc {
 		var e error
 
 		if !status.success {
+			// failures can tell their details, e.g. problems of request fields
 			content, e = json.Marshal(apienvelope{
-				Header: apiheader{Status: "fail", Message: status.message},
+				Header:   apiheader{Status: "fail", Message: status.message},
+				Response: data,
 			})
 		} else {
 			content, e = json.Marshal(apienvelope{
@@ -132,6 +135,9 @@ func APIResourceHandler(APIResource APIResource) http.HandlerFunc {
 			http.Error(w, e.Error(), http.StatusInternalServerError)
 			return
 		}
+		for key, value := range status.headers {
+			w.Header().Set(key, value)
+		}
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(status.code)
 		w.Write(content)
@@ -143,6 +149,18 @@ func Success(code int) APIStatus {
 	return APIStatus{success: true, code: code, message: ""}
 }
 
+// WithHeader adds a response header to the status
+func (status APIStatus) WithHeader(key, value string) APIStatus {
+	headers := map[string]string{key: value}
+	for k, v := range status.headers {
+		if k != key {
+			headers[k] = v
+		}
+	}
+	status.headers = headers
+	return status
+}
+
 // Fail means API finished unsuccessfully
 func Fail(code int, message string) APIStatus {
 	return APIStatus{success: false, code: code, message: message}
@@ -0,0 +1,222 @@
+// Package jobs runs long-running operations in the background
+// so that requests can return before they finish
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/pottava/golang-microservices/app-aws/app/config"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+)
+
+// States of jobs
+const (
+	StateQueued    = "queued"
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateFailed    = "failed"
+	StateCanceled  = "canceled"
+)
+
+// queueSize is the number of jobs which can wait for workers
+const queueSize = 100
+
+// retention is how long finished jobs can be looked up
+const retention = time.Hour
+
+// ErrQueueFull means that too many jobs are waiting for workers
+var ErrQueueFull = errors.New("too many jobs are waiting, try again later")
+
+// ErrNotFound means that the job does not exist or has been forgotten
+var ErrNotFound = errors.New("the job does not exist")
+
+// ErrFinished means that the job has already finished
+var ErrFinished = errors.New("the job has already finished")
+
+// Progress reports how much of a job has been done
+type Progress func(done, total int)
+
+// Work is an operation to run as a job.
+// It should return soon after the context is canceled.
+type Work func(ctx context.Context, progress Progress) (interface{}, error)
+
+// Job represents an operation running in the background
+type Job struct {
+	ID         string      `json:"id"`
+	Kind       string      `json:"kind"`
+	State      string      `json:"state"`
+	Done       int         `json:"done"`
+	Total      int         `json:"total"`
+	Result     interface{} `json:"result,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	StartedAt  *time.Time  `json:"startedAt,omitempty"`
+	FinishedAt *time.Time  `json:"finishedAt,omitempty"`
+	work       Work
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+// Pool runs jobs with a fixed number of workers
+type Pool struct {
+	queue chan *Job
+	mutex sync.RWMutex
+	jobs  map[string]*Job
+}
+
+// Default is the pool the service runs jobs with
+var Default *Pool
+
+func init() {
+	Default = NewPool(config.NewConfig().JobWorkers)
+}
+
+// NewPool starts workers which run submitted jobs
+func NewPool(workers int) *Pool {
+	if workers <= 0 {
+		workers = 1
+	}
+	pool := &Pool{queue: make(chan *Job, queueSize), jobs: map[string]*Job{}}
+	for i := 0; i < workers; i++ {
+		go pool.work()
+	}
+	return pool
+}
+
+// Submit queues an operation and returns its job
+func (pool *Pool) Submit(kind string, work Work) (*Job, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &Job{
+		ID:        newID(),
+		Kind:      kind,
+		State:     StateQueued,
+		CreatedAt: time.Now(),
+		work:      work,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	pool.mutex.Lock()
+	pool.forget(time.Now())
+	pool.jobs[job.ID] = job
+	queued := job.snapshot()
+	pool.mutex.Unlock()
+
+	select {
+	case pool.queue <- job:
+		return queued, nil
+	default:
+		cancel()
+		pool.mutex.Lock()
+		delete(pool.jobs, job.ID)
+		pool.mutex.Unlock()
+		return nil, ErrQueueFull
+	}
+}
+
+// Get returns a copy of the job as of now
+func (pool *Pool) Get(id string) (*Job, error) {
+	pool.mutex.RLock()
+	defer pool.mutex.RUnlock()
+
+	job, found := pool.jobs[id]
+	if !found {
+		return nil, ErrNotFound
+	}
+	return job.snapshot(), nil
+}
+
+// Cancel stops the job. A queued job never runs, and a running job is told
+// to stop through its context, which takes effect when the work checks it.
+func (pool *Pool) Cancel(id string) (*Job, error) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	job, found := pool.jobs[id]
+	if !found {
+		return nil, ErrNotFound
+	}
+	if job.FinishedAt != nil {
+		return nil, ErrFinished
+	}
+	job.cancel()
+	if job.State == StateQueued {
+		job.finish(nil, context.Canceled)
+	}
+	return job.snapshot(), nil
+}
+
+func (pool *Pool) work() {
+	for job := range pool.queue {
+		pool.mutex.Lock()
+		if job.State != StateQueued {
+			pool.mutex.Unlock()
+			continue
+		}
+		now := time.Now()
+		job.State = StateRunning
+		job.StartedAt = &now
+		pool.mutex.Unlock()
+
+		result, err := pool.run(job)
+
+		pool.mutex.Lock()
+		job.finish(result, err)
+		pool.mutex.Unlock()
+		job.cancel()
+	}
+}
+
+func (pool *Pool) run(job *Job) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logs.Error.Printf("Job %s (%s) panicked: %v", job.ID, job.Kind, r)
+			err = errors.New("the job stopped unexpectedly")
+		}
+	}()
+	return job.work(job.ctx, func(done, total int) {
+		pool.mutex.Lock()
+		job.Done, job.Total = done, total
+		pool.mutex.Unlock()
+	})
+}
+
+// forget removes jobs which finished a while ago
+func (pool *Pool) forget(now time.Time) {
+	for id, job := range pool.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > retention {
+			delete(pool.jobs, id)
+		}
+	}
+}
+
+func (job *Job) finish(result interface{}, err error) {
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Result = result
+	switch {
+	case err == nil:
+		job.State = StateSucceeded
+	case job.ctx.Err() == context.Canceled:
+		job.State = StateCanceled
+		job.Error = err.Error()
+	default:
+		job.State = StateFailed
+		job.Error = err.Error()
+	}
+}
+
+func (job *Job) snapshot() *Job {
+	copied := *job
+	return &copied
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// wait polls the job until it finishes
+func wait(pool *Pool, id string) *Job {
+	for i := 0; i < 200; i++ {
+		if job, _ := pool.Get(id); job != nil && job.FinishedAt != nil {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return nil
+}
+
+func TestSubmit(t *testing.T) {
+	pool := NewPool(2)
+	job, err := pool.Submit("test", func(ctx context.Context, progress Progress) (interface{}, error) {
+		progress(1, 2)
+		progress(2, 2)
+		return "done", nil
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if job.State != StateQueued {
+		t.Errorf("Expected %v, but got %v", StateQueued, job.State)
+	}
+	actual := wait(pool, job.ID)
+	if actual == nil || actual.State != StateSucceeded || actual.Result != "done" || actual.Done != 2 || actual.Total != 2 {
+		t.Errorf("Expected a succeeded job, but got %v", actual)
+	}
+
+	job, _ = pool.Submit("test", func(ctx context.Context, progress Progress) (interface{}, error) {
+		return nil, errors.New("broken")
+	})
+	if actual = wait(pool, job.ID); actual == nil || actual.State != StateFailed || actual.Error != "broken" {
+		t.Errorf("Expected a failed job, but got %v", actual)
+	}
+
+	job, _ = pool.Submit("test", func(ctx context.Context, progress Progress) (interface{}, error) {
+		panic("unexpected")
+	})
+	if actual = wait(pool, job.ID); actual == nil || actual.State != StateFailed {
+		t.Errorf("Expected a failed job, but got %v", actual)
+	}
+}
+
+func TestCancel(t *testing.T) {
+	pool := NewPool(1)
+	started := make(chan bool)
+	running, _ := pool.Submit("test", func(ctx context.Context, progress Progress) (interface{}, error) {
+		started <- true
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	queued, _ := pool.Submit("test", func(ctx context.Context, progress Progress) (interface{}, error) {
+		t.Errorf("Expected a canceled job not to run")
+		return nil, nil
+	})
+	<-started
+
+	if actual, err := pool.Cancel(queued.ID); err != nil || actual.State != StateCanceled {
+		t.Errorf("Expected a canceled job, but got %v %v", actual, err)
+	}
+	if _, err := pool.Cancel(running.ID); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if actual := wait(pool, running.ID); actual == nil || actual.State != StateCanceled {
+		t.Errorf("Expected a canceled job, but got %v", actual)
+	}
+	if _, err := pool.Cancel(running.ID); err != ErrFinished {
+		t.Errorf("Expected %v, but got %v", ErrFinished, err)
+	}
+	if _, err := pool.Get("nothing"); err != ErrNotFound {
+		t.Errorf("Expected %v, but got %v", ErrNotFound, err)
+	}
+}
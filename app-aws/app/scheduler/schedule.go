@@ -0,0 +1,144 @@
+// Package scheduler starts and stops ec2 instances along their schedules
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Actions the scheduler takes on instances
+const (
+	ActionStart = "start"
+	ActionStop  = "stop"
+)
+
+// Schedule represents hours in which instances should be running
+// e.g. "weekdays-09-19-Asia/Tokyo" runs from 9:00 to 19:00 in Tokyo, Monday to Friday
+type Schedule struct {
+	Expression string         `json:"expression"`
+	Start      int            `json:"start"`
+	Stop       int            `json:"stop"`
+	Zone       string         `json:"zone"`
+	Days       []time.Weekday `json:"-"`
+	Location   *time.Location `json:"-"`
+}
+
+// Transition represents an action to be taken at a time
+type Transition struct {
+	Action string    `json:"action"`
+	At     time.Time `json:"at"`
+}
+
+// Parse parses a schedule expression like "{days}-{start hour}-{stop hour}[-{time zone}]".
+// Days are one of weekdays, weekends or daily, and the time zone is UTC by default.
+// Time zones are IANA names like Asia/Tokyo, which follow daylight saving time,
+// or abbreviations which mean only one zone like JST.
+// Abbreviations like CST are rejected because they are ambiguous.
+func Parse(expression string) (*Schedule, error) {
+	parts := strings.SplitN(strings.TrimSpace(expression), "-", 4)
+	if len(parts) != 3 && len(parts) != 4 {
+		return nil, fmt.Errorf("schedule %q should be like weekdays-09-19-Asia/Tokyo", expression)
+	}
+	schedule := &Schedule{Expression: expression, Zone: "UTC", Location: time.UTC}
+
+	switch strings.ToLower(parts[0]) {
+	case "weekdays":
+		schedule.Days = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+	case "weekends":
+		schedule.Days = []time.Weekday{time.Saturday, time.Sunday}
+	case "daily", "everyday":
+		schedule.Days = []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+	default:
+		return nil, fmt.Errorf("days of schedule %q should be weekdays, weekends or daily", expression)
+	}
+	var err error
+	if schedule.Start, err = strconv.Atoi(parts[1]); err != nil || schedule.Start < 0 || schedule.Start > 23 {
+		return nil, fmt.Errorf("start hour of schedule %q should be 0-23", expression)
+	}
+	if schedule.Stop, err = strconv.Atoi(parts[2]); err != nil || schedule.Stop < 1 || schedule.Stop > 24 {
+		return nil, fmt.Errorf("stop hour of schedule %q should be 1-24", expression)
+	}
+	if schedule.Start >= schedule.Stop {
+		return nil, fmt.Errorf("start hour of schedule %q should be before its stop hour", expression)
+	}
+	if len(parts) == 4 && !strings.EqualFold(parts[3], "UTC") {
+		location, err := loadLocation(parts[3])
+		if err != nil {
+			return nil, fmt.Errorf("time zone of schedule %q %v", expression, err)
+		}
+		schedule.Zone = location.String()
+		schedule.Location = location
+	}
+	return schedule, nil
+}
+
+// zoneAbbreviations are abbreviations which mean only one zone
+var zoneAbbreviations = map[string]string{
+	"JST": "Asia/Tokyo",
+	"KST": "Asia/Seoul",
+	"HKT": "Asia/Hong_Kong",
+	"SGT": "Asia/Singapore",
+	"WIB": "Asia/Jakarta",
+	"PHT": "Asia/Manila",
+}
+
+// loadLocation loads a time zone by its IANA name or an unambiguous abbreviation.
+// Other names without an area, which the database also has for abbreviations
+// like EST, are rejected.
+func loadLocation(name string) (*time.Location, error) {
+	if zone, found := zoneAbbreviations[strings.ToUpper(name)]; found {
+		name = zone
+	}
+	if !strings.Contains(name, "/") {
+		return nil, fmt.Errorf("should be an IANA name like Asia/Tokyo or an unambiguous abbreviation like JST, not %s", name)
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("is unknown: %s", name)
+	}
+	return location, nil
+}
+
+// Running tells if instances should be running at the time
+func (schedule *Schedule) Running(t time.Time) bool {
+	t = t.In(schedule.Location)
+	return schedule.on(t.Weekday()) && schedule.Start <= t.Hour() && t.Hour() < schedule.Stop
+}
+
+// Transitions returns actions which are scheduled in (from, to]
+func (schedule *Schedule) Transitions(from, to time.Time) []Transition {
+	transitions := []Transition{}
+	day := from.In(schedule.Location)
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, schedule.Location)
+
+	for ; !day.After(to); day = day.AddDate(0, 0, 1) {
+		if !schedule.on(day.Weekday()) {
+			continue
+		}
+		// hours are counted on the wall clock, which can be 23 or 25 hours a day with daylight saving time
+		for _, transition := range []Transition{
+			Transition{Action: ActionStart, At: schedule.at(day, schedule.Start)},
+			Transition{Action: ActionStop, At: schedule.at(day, schedule.Stop)},
+		} {
+			if transition.At.After(from) && !transition.At.After(to) {
+				transitions = append(transitions, transition)
+			}
+		}
+	}
+	return transitions
+}
+
+func (schedule *Schedule) at(day time.Time, hour int) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, schedule.Location)
+}
+
+func (schedule *Schedule) on(weekday time.Weekday) bool {
+	for _, day := range schedule.Days {
+		if day == weekday {
+			return true
+		}
+	}
+	return false
+}
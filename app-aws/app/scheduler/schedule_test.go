@@ -0,0 +1,117 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+var jst = time.FixedZone("JST", 9*60*60)
+
+func TestParse(t *testing.T) {
+	actual, err := Parse("weekdays-09-19-Asia/Tokyo")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if actual.Start != 9 || actual.Stop != 19 || actual.Zone != "Asia/Tokyo" || len(actual.Days) != 5 {
+		t.Errorf("Expected weekdays 9-19 in Tokyo, but got %v", actual)
+	}
+	actual, err = Parse("weekdays-09-19-JST")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if actual.Zone != "Asia/Tokyo" || actual.Location.String() != "Asia/Tokyo" {
+		t.Errorf("Expected JST to be Asia/Tokyo, but got %v", actual.Zone)
+	}
+	actual, err = Parse("daily-8-20")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if actual.Zone != "UTC" || len(actual.Days) != 7 {
+		t.Errorf("Expected daily in UTC, but got %v", actual)
+	}
+	for _, invalid := range []string{"", "weekdays", "someday-09-19", "weekdays-19-09", "weekdays-09-25", "weekdays-09-19-XYZ",
+		"weekdays-09-19-CST", "weekdays-09-19-IST", "weekdays-09-19-EST", "weekdays-09-19-Asia/Nowhere"} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("Expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestRunning(t *testing.T) {
+	schedule, _ := Parse("weekdays-09-19-Asia/Tokyo")
+
+	// 2016-01-04 is a Monday
+	cases := map[time.Time]bool{
+		time.Date(2016, 1, 4, 8, 59, 0, 0, jst):     false,
+		time.Date(2016, 1, 4, 9, 0, 0, 0, jst):      true,
+		time.Date(2016, 1, 4, 18, 59, 0, 0, jst):    true,
+		time.Date(2016, 1, 4, 19, 0, 0, 0, jst):     false,
+		time.Date(2016, 1, 4, 1, 0, 0, 0, time.UTC): true,
+		time.Date(2016, 1, 9, 12, 0, 0, 0, jst):     false,
+	}
+	for at, expected := range cases {
+		if actual := schedule.Running(at); actual != expected {
+			t.Errorf("Expected %v at %v, but got %v", expected, at, actual)
+		}
+	}
+}
+
+func TestTransitions(t *testing.T) {
+	schedule, _ := Parse("weekdays-09-19-Asia/Tokyo")
+
+	// from Friday noon to Monday noon
+	actual := schedule.Transitions(time.Date(2016, 1, 8, 12, 0, 0, 0, jst), time.Date(2016, 1, 11, 12, 0, 0, 0, jst))
+	expected := []Transition{
+		Transition{Action: ActionStop, At: time.Date(2016, 1, 8, 19, 0, 0, 0, jst)},
+		Transition{Action: ActionStart, At: time.Date(2016, 1, 11, 9, 0, 0, 0, jst)},
+	}
+	if len(actual) != len(expected) {
+		t.Errorf("Expected %v, but got %v", expected, actual)
+		return
+	}
+	for idx := range expected {
+		if actual[idx].Action != expected[idx].Action || !actual[idx].At.Equal(expected[idx].At) {
+			t.Errorf("Expected %v, but got %v", expected[idx], actual[idx])
+		}
+	}
+}
+
+func TestDaylightSavingTime(t *testing.T) {
+	schedule, err := Parse("weekdays-09-17-America/New_York")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	// 9:00 in New York is 14:00 UTC in winter, and 13:00 UTC in summer
+	cases := map[time.Time]bool{
+		time.Date(2016, 1, 4, 13, 30, 0, 0, time.UTC): false,
+		time.Date(2016, 1, 4, 14, 30, 0, 0, time.UTC): true,
+		time.Date(2016, 7, 4, 13, 30, 0, 0, time.UTC): true,
+		time.Date(2016, 7, 4, 21, 30, 0, 0, time.UTC): false,
+	}
+	for at, expected := range cases {
+		if actual := schedule.Running(at); actual != expected {
+			t.Errorf("Expected %v at %v, but got %v", expected, at, actual)
+		}
+	}
+	// daylight saving time starts on 2016-03-13, a Sunday
+	actual := schedule.Transitions(time.Date(2016, 3, 11, 0, 0, 0, 0, time.UTC), time.Date(2016, 3, 15, 0, 0, 0, 0, time.UTC))
+	expected := []time.Time{
+		time.Date(2016, 3, 11, 14, 0, 0, 0, time.UTC),
+		time.Date(2016, 3, 11, 22, 0, 0, 0, time.UTC),
+		time.Date(2016, 3, 14, 13, 0, 0, 0, time.UTC),
+		time.Date(2016, 3, 14, 21, 0, 0, 0, time.UTC),
+	}
+	if len(actual) != len(expected) {
+		t.Errorf("Expected %v, but got %v", expected, actual)
+		return
+	}
+	for idx := range expected {
+		if !actual[idx].At.Equal(expected[idx]) {
+			t.Errorf("Expected %v, but got %v", expected[idx], actual[idx].At)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	awssdk "github.com/aws/aws-sdk-go/aws"
+	"github.com/pottava/golang-microservices/app-aws/app/aws"
+	"github.com/pottava/golang-microservices/app-aws/app/config"
+	"github.com/pottava/golang-microservices/app-aws/app/logs"
+)
+
+// TagKey is the tag which holds a schedule expression or a name of a stored definition
+const TagKey = "Schedule"
+
+// Clock tells the current time
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Instance represents an instance which has a schedule tag
+type Instance struct {
+	InstanceID string `json:"instanceId"`
+	State      string `json:"state"`
+	Schedule   string `json:"schedule"`
+}
+
+// Instances lists scheduled instances, and starts or stops them
+type Instances interface {
+	Scheduled() ([]*Instance, error)
+	Start(id string) error
+	Stop(id string) error
+}
+
+// Plan represents an action which is going to be taken on an instance
+type Plan struct {
+	InstanceID string    `json:"instanceId"`
+	Schedule   string    `json:"schedule"`
+	State      string    `json:"state"`
+	Action     string    `json:"action,omitempty"`
+	At         time.Time `json:"at,omitempty"`
+	Message    string    `json:"message,omitempty"`
+}
+
+// Scheduler starts and stops instances when their schedules say so.
+// It only acts on transitions of schedules, so instances which are started
+// or stopped by hand stay as they are until the next transition.
+type Scheduler struct {
+	Clock     Clock
+	Instances Instances
+	Store     *Store
+	mutex     sync.Mutex
+	last      time.Time
+}
+
+// Default is the scheduler the service runs with
+var Default *Scheduler
+
+func init() {
+	path := config.NewConfig().ScheduleStore
+	store, err := NewStore(path)
+	if err != nil {
+		logs.Error.Printf("Could not load schedules from %s. Error: %v", path, err)
+		store, _ = NewStore("")
+	}
+	Default = &Scheduler{Clock: systemClock{}, Instances: ec2Instances{}, Store: store}
+}
+
+// Resolve finds the schedule of a tag value,
+// which is a name of a stored definition or an expression
+func (s *Scheduler) Resolve(value string) (*Schedule, error) {
+	if expression, found := s.Store.Defined()[value]; found {
+		return Parse(expression)
+	}
+	return Parse(value)
+}
+
+// Preview returns the next actions which are scheduled within the duration
+func (s *Scheduler) Preview(within time.Duration) ([]*Plan, error) {
+	instances, err := s.Instances.Scheduled()
+	if err != nil {
+		return nil, err
+	}
+	now := s.Clock.Now()
+	plans := []*Plan{}
+	for _, instance := range instances {
+		schedule, err := s.Resolve(instance.Schedule)
+		if err != nil {
+			plans = append(plans, &Plan{
+				InstanceID: instance.InstanceID,
+				Schedule:   instance.Schedule,
+				State:      instance.State,
+				Message:    err.Error(),
+			})
+			continue
+		}
+		for _, transition := range schedule.Transitions(now, now.Add(within)) {
+			plans = append(plans, &Plan{
+				InstanceID: instance.InstanceID,
+				Schedule:   instance.Schedule,
+				State:      instance.State,
+				Action:     transition.Action,
+				At:         transition.At,
+			})
+		}
+	}
+	sort.SliceStable(plans, func(i, j int) bool {
+		return plans[i].At.Before(plans[j].At)
+	})
+	return plans, nil
+}
+
+// Tick takes the actions which have been scheduled since the last tick.
+// The first tick only remembers the time.
+func (s *Scheduler) Tick() ([]*Record, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.Clock.Now()
+	from := s.last
+	if from.IsZero() {
+		s.last = now
+		return []*Record{}, nil
+	}
+	instances, err := s.Instances.Scheduled()
+	if err != nil {
+		return nil, err
+	}
+	s.last = now
+
+	records := []*Record{}
+	for _, instance := range instances {
+		schedule, err := s.Resolve(instance.Schedule)
+		if err != nil {
+			logs.Warn.Printf("Invalid schedule of %s. Error: %v", instance.InstanceID, err)
+			continue
+		}
+		transitions := schedule.Transitions(from, now)
+		if len(transitions) == 0 {
+			continue
+		}
+		// only the latest one matters when some ticks were missed
+		action := transitions[len(transitions)-1].Action
+
+		var act func(string) error
+		switch {
+		case action == ActionStart && instance.State == "stopped":
+			act = s.Instances.Start
+		case action == ActionStop && instance.State == "running":
+			act = s.Instances.Stop
+		default:
+			continue
+		}
+		record := &Record{
+			At:         now,
+			InstanceID: instance.InstanceID,
+			Schedule:   instance.Schedule,
+			Action:     action,
+			Success:    true,
+		}
+		if err := act(instance.InstanceID); err != nil {
+			record.Success = false
+			record.Message = err.Error()
+		}
+		logs.Info.Printf("[scheduler] %s %s: %v %s", action, instance.InstanceID, record.Success, record.Message)
+		records = append(records, record)
+	}
+	if err := s.Store.Record(records...); err != nil {
+		return records, fmt.Errorf("could not persist records: %v", err)
+	}
+	return records, nil
+}
+
+// Run ticks at every interval, and never returns
+func (s *Scheduler) Run(every time.Duration) {
+	s.Tick()
+	for range time.Tick(every) {
+		if _, err := s.Tick(); err != nil {
+			logs.Error.Printf("[scheduler] %v", err)
+		}
+	}
+}
+
+type ec2Instances struct{}
+
+func (ec2Instances) Scheduled() ([]*Instance, error) {
+	page, err := aws.Ec2Instances(aws.Ec2InstanceQuery{
+		States:   []string{"running", "stopped"},
+		TagKeys:  []string{TagKey},
+		AllPages: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	instances := []*Instance{}
+	for _, instance := range page.Instances {
+		scheduled := &Instance{InstanceID: awssdk.StringValue(instance.InstanceId)}
+		if instance.State != nil {
+			scheduled.State = awssdk.StringValue(instance.State.Name)
+		}
+		for _, tag := range instance.Tags {
+			if awssdk.StringValue(tag.Key) == TagKey {
+				scheduled.Schedule = awssdk.StringValue(tag.Value)
+			}
+		}
+		instances = append(instances, scheduled)
+	}
+	return instances, nil
+}
+
+func (ec2Instances) Start(id string) error {
+	_, err := aws.Ec2StartInstance(id, false)
+	return err
+}
+
+func (ec2Instances) Stop(id string) error {
+	_, err := aws.Ec2StopInstance(id, false)
+	return err
+}
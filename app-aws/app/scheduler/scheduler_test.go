@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time {
+	return c.now
+}
+
+type fakeInstances struct {
+	instances []*Instance
+	actions   []string
+}
+
+func (f *fakeInstances) Scheduled() ([]*Instance, error) {
+	return f.instances, nil
+}
+
+func (f *fakeInstances) Start(id string) error {
+	f.actions = append(f.actions, "start "+id)
+	return nil
+}
+
+func (f *fakeInstances) Stop(id string) error {
+	f.actions = append(f.actions, "stop "+id)
+	return nil
+}
+
+func TestTick(t *testing.T) {
+	clock := &fixedClock{now: time.Date(2016, 1, 4, 8, 55, 0, 0, jst)}
+	instances := &fakeInstances{instances: []*Instance{
+		&Instance{InstanceID: "i-1", State: "stopped", Schedule: "weekdays-09-19-Asia/Tokyo"},
+		&Instance{InstanceID: "i-2", State: "running", Schedule: "weekdays-09-19-Asia/Tokyo"},
+		&Instance{InstanceID: "i-3", State: "stopped", Schedule: "office"},
+		&Instance{InstanceID: "i-4", State: "stopped", Schedule: "broken"},
+	}}
+	store, _ := NewStore("")
+	store.Define("office", "daily-09-18-Asia/Tokyo")
+	s := &Scheduler{Clock: clock, Instances: instances, Store: store}
+
+	records, _ := s.Tick()
+	if len(records) != 0 {
+		t.Errorf("Expected the first tick to take no action, but got %v", records)
+		return
+	}
+	clock.now = clock.now.Add(10 * time.Minute)
+	records, err := s.Tick()
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	expected := []string{"start i-1", "start i-3"}
+	if len(instances.actions) != len(expected) || instances.actions[0] != expected[0] || instances.actions[1] != expected[1] {
+		t.Errorf("Expected %v, but got %v", expected, instances.actions)
+		return
+	}
+	if len(store.History()) != 2 || store.History()[0].InstanceID != "i-3" {
+		t.Errorf("Expected 2 records with the latest first, but got %v", store.History())
+	}
+
+	// nothing is scheduled until 18:00
+	clock.now = clock.now.Add(time.Hour)
+	if records, _ = s.Tick(); len(records) != 0 {
+		t.Errorf("Expected no action, but got %v", records)
+	}
+}
+
+func TestPreview(t *testing.T) {
+	clock := &fixedClock{now: time.Date(2016, 1, 8, 12, 0, 0, 0, jst)}
+	store, _ := NewStore("")
+	s := &Scheduler{Clock: clock, Store: store, Instances: &fakeInstances{instances: []*Instance{
+		&Instance{InstanceID: "i-1", State: "running", Schedule: "weekdays-09-19-Asia/Tokyo"},
+	}}}
+
+	actual, err := s.Preview(72 * time.Hour)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if len(actual) != 2 || actual[0].Action != ActionStop || actual[1].Action != ActionStart {
+		t.Errorf("Expected a stop on Friday and a start on Monday, but got %v", actual)
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scheduler")
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "schedules.json")
+
+	store, _ := NewStore(path)
+	store.Define("office", "weekdays-09-19-Asia/Tokyo")
+	store.Record(&Record{InstanceID: "i-1", Action: ActionStart, Success: true})
+
+	actual, err := NewStore(path)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+		return
+	}
+	if actual.Defined()["office"] != "weekdays-09-19-Asia/Tokyo" || len(actual.History()) != 1 {
+		t.Errorf("Expected the definition and the record to be persisted, but got %v", actual)
+	}
+}
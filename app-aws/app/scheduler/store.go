@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// maxRecords is the number of actions the store remembers
+const maxRecords = 1000
+
+// Record represents an action the scheduler has taken
+type Record struct {
+	At         time.Time `json:"at"`
+	InstanceID string    `json:"instanceId"`
+	Schedule   string    `json:"schedule"`
+	Action     string    `json:"action"`
+	Success    bool      `json:"success"`
+	Message    string    `json:"message,omitempty"`
+}
+
+// Store persists schedule definitions and records of actions as a json file.
+// Nothing is written to the disk when its path is empty.
+type Store struct {
+	path        string
+	mutex       sync.RWMutex
+	Definitions map[string]string `json:"definitions"`
+	Records     []*Record         `json:"records"`
+}
+
+// NewStore loads a store from the path
+func NewStore(path string) (*Store, error) {
+	store := &Store{path: path, Definitions: map[string]string{}, Records: []*Record{}}
+	if path == "" {
+		return store, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(data, store); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Defined returns a copy of the stored schedule definitions
+func (store *Store) Defined() map[string]string {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	definitions := map[string]string{}
+	for name, expression := range store.Definitions {
+		definitions[name] = expression
+	}
+	return definitions
+}
+
+// Define stores a named schedule definition
+func (store *Store) Define(name, expression string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.Definitions[name] = expression
+	return store.save()
+}
+
+// Undefine removes a named schedule definition
+func (store *Store) Undefine(name string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.Definitions, name)
+	return store.save()
+}
+
+// Record appends records of actions, forgetting the oldest ones
+func (store *Store) Record(records ...*Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.Records = append(store.Records, records...)
+	if len(store.Records) > maxRecords {
+		store.Records = store.Records[len(store.Records)-maxRecords:]
+	}
+	return store.save()
+}
+
+// History returns records of actions, the latest first
+func (store *Store) History() []*Record {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	history := make([]*Record, len(store.Records))
+	for idx, record := range store.Records {
+		history[len(history)-1-idx] = record
+	}
+	return history
+}
+
+// save writes the store to a temporary file and then renames it,
+// so that a crash does not leave a broken file
+func (store *Store) save() error {
+	if store.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(store)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(store.path), 0755); err != nil {
+		return err
+	}
+	temp := store.path + ".tmp"
+	if err = ioutil.WriteFile(temp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp, store.path)
+}
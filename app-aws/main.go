@@ -7,11 +7,16 @@ import (
 	"github.com/pottava/golang-microservices/app-aws/app/config"
 	_ "github.com/pottava/golang-microservices/app-aws/app/controllers"
 	"github.com/pottava/golang-microservices/app-aws/app/logs"
+	"github.com/pottava/golang-microservices/app-aws/app/scheduler"
 )
 
 func main() {
 	cfg := config.NewConfig()
 	logs.Debug.Print("[config] " + cfg.String())
+	if cfg.ScheduleEvery > 0 {
+		logs.Info.Printf("[scheduler] running every %v", cfg.ScheduleEvery)
+		go scheduler.Default.Run(cfg.ScheduleEvery)
+	}
 	logs.Info.Printf("[service] listening on port %v", cfg.Port)
 	logs.Fatal.Print(http.ListenAndServe(":"+fmt.Sprint(cfg.Port), nil))
 }
@@ -6,8 +6,10 @@ type EC2Instance struct {
 }
 
 type daoEC2Instance struct {
-	Header   APIHeader      `json:"header"`
-	Response []*EC2Instance `json:"response"`
+	Header   APIHeader `json:"header"`
+	Response struct {
+		Instances []*EC2Instance `json:"instances"`
+	} `json:"response"`
 }
 
 // GetEC2Instances retrives ec2 instances
@@ -15,7 +17,7 @@ func GetEC2Instances() (instances []*EC2Instance, found bool) {
 	res := &daoEC2Instance{}
 	if err := aws("GET", "/ec2/instances/", "", res); err == nil {
 		if res.Header.Status == "success" {
-			return res.Response, true
+			return res.Response.Instances, true
 		}
 	}
 	return []*EC2Instance{}, false